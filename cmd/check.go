@@ -4,7 +4,11 @@ Copyright © 2023 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
@@ -23,9 +27,9 @@ func Execute() {
 	}
 }
 
-var apps, components []string
-var baseDir string
-var verbose bool
+var apps, rootApps, components, exclude, allowedRegistries []string
+var baseDir, kubeVersion, argocdNamespace, clusters, output, policyDir, configFile, remoteResources, lockFile, buildOptions, argocdCM, helmChartHome string
+var verbose, strict, enableHelm, stubSOPS, watchMode bool
 
 // checkCmd represents the base command when called without any subcommands
 var checkCmd = &cobra.Command{
@@ -34,23 +38,25 @@ var checkCmd = &cobra.Command{
 
 	Run: func(cmd *cobra.Command, args []string) {
 
-		logger := charmlog.New(cmd.OutOrStderr())
-		logger.SetLevel(charmlog.InfoLevel)
-		if verbose {
-			logger.SetLevel(charmlog.DebugLevel)
-		}
-
+		logger := newLogger(cmd)
 		afs := afero.Afero{
 			Fs: afero.NewOsFs(),
 		}
 
-		// verifies that the source path of the Applications and ApplicationSets exists
-		if err := validation.CheckApplications(logger, afs, baseDir, apps...); err != nil {
+		if watchMode {
+			if err := watch(cmd, logger, afs); err != nil {
+				logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+				os.Exit(1)
+			}
+			return
+		}
+		opts, cfg, err := newOptions(cmd, logger, afs, baseDir)
+		if err != nil {
 			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
 			os.Exit(1)
 		}
-		// verifies that `kustomize build` on each component completes successfully
-		if err := validation.CheckComponents(logger, afs, baseDir, components...); err != nil {
+		setOutput(logger, cfg.Output)
+		if err := runChecks(cmd.Context(), cmd.OutOrStdout(), logger, afs, opts, cfg); err != nil {
 			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
 			os.Exit(1)
 		}
@@ -58,15 +64,173 @@ var checkCmd = &cobra.Command{
 	},
 }
 
+func runChecks(ctx context.Context, out io.Writer, logger validation.Logger, afs afero.Afero, opts validation.Options, cfg validation.Config) error {
+	// verifies the app-of-apps trees from the root Applications, the Applications and ApplicationSets (metadata,
+	// destination and source paths) and the components (`kustomize build` and the rendered objects)
+	report, err := validation.NewChecker(
+		validation.WithFs(afs.Fs),
+		validation.WithLogger(logger),
+		validation.WithOptions(opts),
+		validation.WithBaseDir(baseDir),
+		validation.WithRootApps(cfg.RootApps...),
+		validation.WithApps(cfg.Apps...),
+		validation.WithComponents(cfg.Components...),
+		validation.WithBases(cfg.Bases...),
+	).Run(ctx)
+	if report.Applications != nil {
+		if err := validation.WriteApplicationTree(out, report.Applications); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+func newLogger(cmd *cobra.Command) *charmlog.Logger {
+	logger := charmlog.New(cmd.OutOrStderr())
+	logger.SetLevel(charmlog.InfoLevel)
+	if verbose {
+		logger.SetLevel(charmlog.DebugLevel)
+	}
+	return logger
+}
+
+// sets the formatter of the logger which prints the findings
+func setOutput(logger *charmlog.Logger, output validation.OutputFormat) {
+	switch output {
+	case validation.OutputJSON:
+		logger.SetFormatter(charmlog.JSONFormatter)
+	case validation.OutputLogfmt:
+		logger.SetFormatter(charmlog.LogfmtFormatter)
+	default:
+		logger.SetFormatter(charmlog.TextFormatter)
+	}
+}
+
+// loads the configuration file, the policies and the lockfile of the given base directory to setup the checks.
+// The returned configuration includes the values of the flags, which override the values of the file.
+func newOptions(cmd *cobra.Command, logger validation.Logger, afs afero.Afero, baseDir string) (validation.Options, validation.Config, error) {
+	cfg, err := loadConfig(cmd, logger, afs, baseDir)
+	if err != nil {
+		return validation.Options{}, cfg, err
+	}
+	rules := validation.NewRegistry()
+	if policyDir != "" {
+		policies, err := validation.LoadPolicies(logger, afs, policyDir)
+		if err != nil {
+			return validation.Options{}, cfg, err
+		}
+		for _, p := range policies {
+			if err := rules.Register(p.Rule()); err != nil {
+				return validation.Options{}, cfg, err
+			}
+		}
+	}
+	if err := rules.Configure(cfg.Rules); err != nil {
+		return validation.Options{}, cfg, err
+	}
+	opts := validation.Options{
+		Rules:             rules,
+		Strict:            strict,
+		RemoteResources:   validation.RemoteResourcesMode(remoteResources),
+		EnableHelm:        enableHelm,
+		HelmChartHome:     helmChartHome,
+		StubSOPS:          stubSOPS,
+		Exclude:           cfg.Exclude,
+		KubeVersion:       cfg.KubeVersion,
+		ArgoCDNamespace:   cfg.ArgoCDNamespace,
+		AllowedRegistries: cfg.AllowedRegistries,
+		ExternalObjects:   cfg.ExternalObjects,
+	}
+	if cfg.Clusters != "" {
+		path := clustersPath(baseDir, cfg)
+		logger.Debug("loading clusters", "path", path)
+		if opts.Clusters, err = validation.LoadClusters(afs, path); err != nil {
+			return validation.Options{}, cfg, err
+		}
+	}
+	switch opts.RemoteResources {
+	case validation.RemoteResourcesFail, validation.RemoteResourcesSkip:
+	case validation.RemoteResourcesVendor:
+		path := lockFile
+		if path == "" {
+			path = filepath.Join(baseDir, validation.LockFile)
+		}
+		logger.Debug("loading lockfile", "path", path)
+		if opts.VendoredResources, err = validation.LoadLockFile(afs, path); err != nil {
+			return validation.Options{}, cfg, err
+		}
+	default:
+		return validation.Options{}, cfg, fmt.Errorf("invalid '--remote-resources' mode: '%s'", remoteResources)
+	}
+	switch {
+	case buildOptions != "":
+		if opts.BuildOptions, err = validation.ParseBuildOptions(buildOptions); err != nil {
+			return validation.Options{}, cfg, err
+		}
+	case argocdCM != "":
+		logger.Debug("loading kustomize build options", "path", argocdCM)
+		if opts.BuildOptions, err = validation.LoadBuildOptions(afs, argocdCM); err != nil {
+			return validation.Options{}, cfg, err
+		}
+	}
+	if cfg.Apps, err = validation.ExpandPaths(afs, baseDir, cfg.Apps...); err != nil {
+		return validation.Options{}, cfg, err
+	}
+	if cfg.Components, err = validation.ExpandPaths(afs, baseDir, cfg.Components...); err != nil {
+		return validation.Options{}, cfg, err
+	}
+	if cfg.RootApps, err = validation.ExpandPaths(afs, baseDir, cfg.RootApps...); err != nil {
+		return validation.Options{}, cfg, err
+	}
+	if len(cfg.Apps) == 0 && len(cfg.RootApps) == 0 && len(cfg.Components) == 0 {
+		// neither flags nor configuration: discover the layout of the repository
+		layout, err := validation.Discover(logger, afs, opts, baseDir)
+		if err != nil {
+			return validation.Options{}, cfg, err
+		}
+		cfg.Apps, cfg.Components, cfg.Bases = layout.Apps, layout.Components, layout.Bases
+	}
+	return opts, cfg, nil
+}
+
+// returns the path of the clusters file or directory, relative to the given base directory unless it is absolute
+func clustersPath(baseDir string, cfg validation.Config) string {
+	if filepath.IsAbs(cfg.Clusters) {
+		return cfg.Clusters
+	}
+	return filepath.Join(baseDir, cfg.Clusters)
+}
+
 func init() {
-	checkCmd.Flags().StringSliceVar(&apps, "apps", []string{}, "path(s) to the applications (comma-separated, relative to '--baseDir')")
+	checkCmd.PersistentFlags().StringSliceVar(&apps, "apps", []string{}, "path(s) or pattern(s) of the applications (comma-separated, relative to '--base-dir', discovered if neither '--apps' nor '--components' is set)")
 	// if err := checkCmd.MarkFlagRequired("apps"); err != nil {
 	// 	panic(fmt.Sprintf("failed to mark flag as required: %s", err))
 	// }
-	checkCmd.Flags().StringVar(&baseDir, "base-dir", ".", "base directory of the repository")
-	checkCmd.Flags().StringSliceVar(&components, "components", []string{}, "path(s) to the components (comma-separated, relative to '--baseDir')")
+	checkCmd.PersistentFlags().StringSliceVar(&rootApps, "root-apps", []string{}, "path(s) or pattern(s) of the root Applications of an app-of-apps tree, whose rendered Applications are checked recursively (comma-separated, relative to '--base-dir')")
+	checkCmd.PersistentFlags().StringVar(&baseDir, "base-dir", ".", "base directory of the repository")
+	checkCmd.PersistentFlags().StringSliceVar(&components, "components", []string{}, "path(s) or pattern(s) of the components (comma-separated, relative to '--base-dir', discovered if neither '--apps' nor '--components' is set)")
 	// if err := checkCmd.MarkFlagRequired("components"); err != nil {
 	// 	panic(fmt.Sprintf("failed to mark flag as required: %s", err))
 	// }
-	checkCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	checkCmd.PersistentFlags().StringSliceVar(&exclude, "exclude", []string{}, "pattern(s) of the paths to skip (comma-separated, relative to '--base-dir')")
+	checkCmd.PersistentFlags().StringVar(&kubeVersion, "kube-version", "", "Kubernetes version of the target clusters (eg: '1.27')")
+	checkCmd.PersistentFlags().StringVar(&argocdNamespace, "argocd-namespace", validation.DefaultArgoCDNamespace, "namespace of the Applications and ApplicationSets")
+	checkCmd.PersistentFlags().StringVar(&clusters, "clusters", "", "path of a clusters file, or of a file or directory of Argo CD cluster Secrets, to verify the destinations of the Applications (relative to '--base-dir')")
+	checkCmd.PersistentFlags().StringSliceVar(&allowedRegistries, "allowed-registries", []string{}, "registries of the container images, which may include a path (comma-separated, eg: 'quay.io/org,registry.k8s.io'). The registries are not verified if empty")
+	checkCmd.Flags().StringVar(&output, "output", string(validation.OutputText), "output format: 'text', 'json' or 'logfmt'")
+	checkCmd.PersistentFlags().StringVar(&policyDir, "policy-dir", "", "directory of the policy files (CEL expressions, Rego is not supported) to evaluate against the Applications and the rendered objects")
+	checkCmd.PersistentFlags().StringVar(&configFile, "config", "", "path to the configuration file (defaults to '"+validation.ConfigFile+"' in '--base-dir', if it exists)")
+	checkCmd.PersistentFlags().StringVar(&remoteResources, "remote-resources", string(validation.RemoteResourcesFail), "how to handle the remote resources of the kustomizations: 'fail', 'skip' or 'vendor'")
+	checkCmd.PersistentFlags().StringVar(&lockFile, "remote-lockfile", "", "path to the lockfile of the vendored remote resources, in the 'vendor' mode (defaults to '"+validation.LockFile+"' in '--base-dir')")
+	checkCmd.PersistentFlags().StringVar(&buildOptions, "kustomize-build-options", "", "options of 'kustomize build', as configured in Argo CD (eg: '--enable-helm --load-restrictor LoadRestrictionsNone')")
+	checkCmd.PersistentFlags().StringVar(&argocdCM, "argocd-cm", "", "path to a local copy of the 'argocd-cm' ConfigMap, to read the '"+validation.BuildOptionsKey+"' (ignored if '--kustomize-build-options' is set)")
+	checkCmd.PersistentFlags().BoolVar(&enableHelm, "enable-helm", false, "inflate the 'helmCharts' of the kustomizations, from the local chart home (same as the '--enable-helm' build option)")
+	checkCmd.PersistentFlags().StringVar(&helmChartHome, "helm-chart-home", "", "local directory of the Helm charts, which overrides the 'helmGlobals.chartHome' of the kustomizations")
+	checkCmd.PersistentFlags().BoolVar(&stubSOPS, "stub-sops", false, "replace the KSOPS generators with placeholder Secrets (same names, types and keys), to build the kustomizations without the SOPS decryption keys")
+	checkCmd.Flags().BoolVar(&strict, "strict", false, "turn all warnings (eg: unreferenced resources) into errors")
+	checkCmd.Flags().BoolVar(&watchMode, "watch", false, "watch '--base-dir' and re-run the checks on the paths affected by the changed files")
+	checkCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 }
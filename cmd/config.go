@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// keys of the configuration file which can be overridden by flags
+var configFlags = map[string]string{
+	"apps":              "apps",
+	"rootApps":          "root-apps",
+	"components":        "components",
+	"exclude":           "exclude",
+	"kubeVersion":       "kube-version",
+	"argocdNamespace":   "argocd-namespace",
+	"clusters":          "clusters",
+	"allowedRegistries": "allowed-registries",
+	"output":            "output",
+}
+
+// returns the path of the configuration file: the '--config' flag, or the file at the root of the repository
+// (empty if it does not exist)
+func configPath(afs afero.Afero, baseDir string) (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+	path := filepath.Join(baseDir, validation.ConfigFile)
+	if exists, err := afs.Exists(path); err != nil || !exists {
+		return "", err
+	}
+	return path, nil
+}
+
+// loads and validates the configuration file (if any), and overrides its values with the flags which were set
+func loadConfig(cmd *cobra.Command, logger validation.Logger, afs afero.Afero, baseDir string) (validation.Config, error) {
+	cfg := validation.Config{}
+	v := viper.New()
+	v.SetFs(afs.Fs)
+	path, err := configPath(afs, baseDir)
+	if err != nil {
+		return cfg, err
+	}
+	if path != "" {
+		logger.Debug("loading configuration", "path", path)
+		// strict loading, to report the unknown fields
+		if cfg, err = validation.LoadConfig(afs, path); err != nil {
+			return cfg, err
+		}
+		v.SetConfigFile(path)
+		v.SetConfigType("yaml")
+		if err := v.ReadInConfig(); err != nil {
+			return cfg, err
+		}
+	}
+	for key, name := range configFlags {
+		if key == "output" && cmd.HasParent() {
+			// the output format of the checks is a flag of the root command only: the subcommands may have their
+			// own `--output` flag (eg: `diff -o markdown`)
+			continue
+		}
+		if f := cmd.Flags().Lookup(name); f != nil {
+			if err := v.BindPFlag(key, f); err != nil {
+				return cfg, err
+			}
+		}
+	}
+	cfg.Apps = v.GetStringSlice("apps")
+	cfg.RootApps = v.GetStringSlice("rootApps")
+	cfg.Components = v.GetStringSlice("components")
+	cfg.Exclude = v.GetStringSlice("exclude")
+	cfg.KubeVersion = v.GetString("kubeVersion")
+	cfg.ArgoCDNamespace = v.GetString("argocdNamespace")
+	cfg.Clusters = v.GetString("clusters")
+	cfg.AllowedRegistries = v.GetStringSlice("allowedRegistries")
+	cfg.Output = validation.OutputFormat(v.GetString("output"))
+	return cfg, cfg.Validate()
+}
+
+// configCmd groups the subcommands about the configuration file
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manages the configuration file",
+}
+
+// configValidateCmd verifies the configuration file
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Verifies the configuration file",
+	Long:  "Verifies the fields and values of the configuration file, including the IDs of the configured rules and policies",
+	Args:  cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger(cmd)
+		afs := afero.Afero{
+			Fs: afero.NewOsFs(),
+		}
+
+		path, err := configPath(afs, baseDir)
+		if err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		if path == "" {
+			logger.Error("no configuration file", "path", filepath.Join(baseDir, validation.ConfigFile))
+			os.Exit(1)
+		}
+		if _, _, err := newOptions(cmd, logger, afs, baseDir); err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		logger.Info("🤙 configuration is valid", "path", path)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	checkCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+var diffFormat string
+
+// diffCmd prints the changes of the manifests rendered from the sources of the Applications between two revisions
+var diffCmd = &cobra.Command{
+	Use:   "diff <old-dir> <new-dir>",
+	Short: "Prints the changes of the rendered manifests between two revisions of the repository",
+	Long: "Builds the sources of the Applications in two local directories (eg: two worktrees of the repository) " +
+		"and prints the added, removed and modified objects and fields of each Application",
+	Args: cobra.ExactArgs(2),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger(cmd)
+		afs := afero.Afero{
+			Fs: afero.NewOsFs(),
+		}
+
+		rendered := make([][]validation.RenderedApplication, len(args))
+		for i, dir := range args {
+			opts, cfg, err := newOptions(cmd, logger, afs, dir)
+			if err != nil {
+				logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+				os.Exit(1)
+			}
+			if rendered[i], err = validation.RenderApplications(logger, afs, opts, dir, cfg.Apps...); err != nil {
+				logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+				os.Exit(1)
+			}
+		}
+		diffs, err := validation.DiffApplications(rendered[0], rendered[1])
+		if err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		if err := validation.WriteDiff(cmd.OutOrStdout(), diffs, validation.DiffFormat(diffFormat)); err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffFormat, "output", "o", string(validation.DiffFormatText), "output format: 'text' or 'markdown'")
+	checkCmd.AddCommand(diffCmd)
+}
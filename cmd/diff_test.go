@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffCmd(t *testing.T) {
+
+	newRepo := func(t *testing.T, cookie string) string {
+		dir := t.TempDir()
+		files := map[string]string{
+			// the configuration file is loaded along with the flags
+			".argocd-checker.yaml": `kubeVersion: "1.27"`,
+			"apps/cookie.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  source:
+    path: components/cookie`,
+			"components/cookie/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+  namespace: cookie
+data:
+  cookie: ` + cookie,
+		}
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		}
+		return dir
+	}
+
+	t.Run("markdown output", func(t *testing.T) {
+		// given
+		oldDir := newRepo(t, "yummy")
+		newDir := newRepo(t, "yucky")
+		out := &bytes.Buffer{}
+		checkCmd.SetOut(out)
+		checkCmd.SetArgs([]string{"diff", oldDir, newDir, "--apps", "apps", "-o", "markdown"})
+		t.Cleanup(func() {
+			checkCmd.SetOut(nil)
+			checkCmd.SetArgs(nil)
+		})
+
+		// when
+		err := checkCmd.Execute()
+
+		// then
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "### Application `cookie`")
+		assert.Contains(t, out.String(), "-   data.cookie: \"yummy\"\n+   data.cookie: \"yucky\"")
+	})
+}
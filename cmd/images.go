@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// imagesCmd prints the container images of the rendered workloads of the Applications
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Prints the container images of the Applications",
+	Long:  "Builds the sources of the Applications and prints the images of the containers of their workloads (Pods, Deployments, StatefulSets, DaemonSets, ReplicaSets, Jobs and CronJobs)",
+	Args:  cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger(cmd)
+		afs := afero.Afero{
+			Fs: afero.NewOsFs(),
+		}
+
+		opts, cfg, err := newOptions(cmd, logger, afs, baseDir)
+		if err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		rendered, err := validation.RenderApplications(logger, afs, opts, baseDir, cfg.Apps...)
+		if err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		if err := validation.WriteImages(cmd.OutOrStdout(), rendered); err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	checkCmd.AddCommand(imagesCmd)
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+var outDir string
+
+// renderCmd writes the manifests rendered from the sources of the Applications
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Writes the manifests rendered from the sources of the Applications",
+	Long:  "Builds the sources of the Applications and writes the rendered objects in '<out>/<app-name>/<kind>-<name>.yaml'",
+
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger(cmd)
+		afs := afero.Afero{
+			Fs: afero.NewOsFs(),
+		}
+
+		opts, cfg, err := newOptions(cmd, logger, afs, baseDir)
+		if err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		rendered, err := validation.RenderApplications(logger, afs, opts, baseDir, cfg.Apps...)
+		if err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		if err := validation.WriteRenderedApplications(logger, afs, outDir, rendered); err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringVar(&outDir, "out", "rendered", "output directory of the rendered manifests")
+	checkCmd.AddCommand(renderCmd)
+}
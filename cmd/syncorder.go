@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// syncOrderCmd prints the order in which Argo CD applies the rendered objects of the Applications
+var syncOrderCmd = &cobra.Command{
+	Use:   "sync-order",
+	Short: "Prints the sync order of the objects of the Applications",
+	Long:  "Builds the sources of the Applications and prints the order in which Argo CD applies their objects: by phase, sync wave, kind and name",
+	Args:  cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		logger := newLogger(cmd)
+		afs := afero.Afero{
+			Fs: afero.NewOsFs(),
+		}
+
+		opts, cfg, err := newOptions(cmd, logger, afs, baseDir)
+		if err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		rendered, err := validation.RenderApplications(logger, afs, opts, baseDir, cfg.Apps...)
+		if err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+		if err := validation.WriteSyncOrder(cmd.OutOrStdout(), rendered); err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	checkCmd.AddCommand(syncOrderCmd)
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// delay after the last change before the checks are run again, since editors often write files in several steps
+const watchDebounce = 300 * time.Millisecond
+
+var (
+	watchTitleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
+	watchStatusStyle = lipgloss.NewStyle().Faint(true)
+)
+
+// watches the base directory and re-runs the checks on the kustomizations and Applications affected by the changed
+// files, until the watcher fails
+func watch(cmd *cobra.Command, logger validation.Logger, afs afero.Afero) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err := addWatches(watcher, afs, baseDir); err != nil {
+		return err
+	}
+
+	runWatchedChecks(cmd, logger, afs, nil)
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	changed := map[string]bool{}
+	for {
+		select {
+		case e, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if isGitPath(e.Name) || e.Op == fsnotify.Chmod {
+				continue
+			}
+			if e.Has(fsnotify.Create) {
+				if isDir, _ := afs.IsDir(e.Name); isDir {
+					if err := addWatches(watcher, afs, e.Name); err != nil {
+						return err
+					}
+				}
+			}
+			changed[e.Name] = true
+			timer.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case <-timer.C:
+			paths := make([]string, 0, len(changed))
+			for p := range changed {
+				paths = append(paths, p)
+			}
+			sort.Strings(paths)
+			changed = map[string]bool{}
+			runWatchedChecks(cmd, logger, afs, paths)
+		}
+	}
+}
+
+// adds a watch on the given directory and all its subdirectories, since fsnotify is not recursive
+func addWatches(watcher *fsnotify.Watcher, afs afero.Afero, dir string) error {
+	return afs.Walk(dir, func(path string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+func isGitPath(path string) bool {
+	for _, segment := range strings.Split(filepath.ToSlash(path), "/") {
+		if segment == ".git" {
+			return true
+		}
+	}
+	return false
+}
+
+// clears the screen and runs the checks on the paths affected by the given changed files (all paths if nil, or if
+// the configuration, the policies or the lockfile changed)
+func runWatchedChecks(cmd *cobra.Command, logger validation.Logger, afs afero.Afero, changed []string) {
+	out := cmd.OutOrStderr()
+	fmt.Fprint(out, "\033[H\033[2J")
+	fmt.Fprintln(out, watchTitleStyle.Render(fmt.Sprintf("👀 watching %s", baseDir)))
+	status := fmt.Sprintf("%s - %d changed file(s)", time.Now().Format(time.TimeOnly), len(changed))
+	if changed == nil {
+		status = fmt.Sprintf("%s - checking all paths", time.Now().Format(time.TimeOnly))
+	}
+	fmt.Fprintln(out, watchStatusStyle.Render(status+" - press ctrl+c to quit"))
+	fmt.Fprintln(out)
+
+	opts, cfg, err := newOptions(cmd, logger, afs, baseDir)
+	if err != nil {
+		logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+		return
+	}
+	if changed != nil && !settingsChanged(cfg, changed) {
+		roots := append(append(append(append([]string{}, cfg.Apps...), cfg.RootApps...), cfg.Components...), cfg.Bases...)
+		if opts.Paths, err = validation.AffectedPaths(logger, afs, baseDir, roots, changed...); err != nil {
+			logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+			return
+		}
+		if len(opts.Paths) == 0 {
+			logger.Info("🤷 no kustomization or Application affected by the changes", "changed", changed)
+			return
+		}
+		logger.Info("👀 checking the paths affected by the changes", "paths", len(opts.Paths))
+	}
+	if err := runChecks(cmd.Context(), cmd.OutOrStdout(), logger, afs, opts, cfg); err != nil {
+		logger.Error(strings.ReplaceAll(err.Error(), ": ", ":\n"))
+		return
+	}
+	logger.Info("🤙 all good!")
+}
+
+// returns true if one of the changed files is a setting of the checker (configuration, policies, lockfile, clusters
+// or Argo CD ConfigMap), in which case all paths must be checked again
+func settingsChanged(cfg validation.Config, changed []string) bool {
+	settings := []string{configFile, lockFile, argocdCM, policyDir, filepath.Join(baseDir, validation.ConfigFile), filepath.Join(baseDir, validation.LockFile)}
+	if cfg.Clusters != "" {
+		settings = append(settings, clustersPath(baseDir, cfg))
+	}
+	for _, c := range changed {
+		for _, s := range settings {
+			if s == "" {
+				continue
+			}
+			if rel, err := filepath.Rel(s, c); err == nil && !strings.HasPrefix(rel, "..") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"encoding/json"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -33,7 +35,7 @@ type ApplicationSetSpec struct {
 
 // ApplicationSetGenerator represents a generator at the top level of an ApplicationSet.
 type ApplicationSetGenerator struct {
-	// List                    *ListGenerator        `json:"list,omitempty" protobuf:"bytes,1,name=list"`
+	List     *ListGenerator    `json:"list,omitempty" protobuf:"bytes,1,name=list"`
 	Clusters *ClusterGenerator `json:"clusters,omitempty" protobuf:"bytes,2,name=clusters"`
 	// Git                     *GitGenerator         `json:"git,omitempty" protobuf:"bytes,3,name=git"`
 	// SCMProvider             *SCMProviderGenerator `json:"scmProvider,omitempty" protobuf:"bytes,4,name=scmProvider"`
@@ -64,6 +66,14 @@ type ApplicationSetTemplateMeta struct {
 	Finalizers  []string          `json:"finalizers,omitempty" protobuf:"bytes,5,name=finalizers"`
 }
 
+// ListGenerator include items info
+type ListGenerator struct {
+	// Elements are decoded as raw JSON (instead of `apiextensionsv1.JSON`), to avoid the dependency on the apiextensions module
+	Elements     []json.RawMessage      `json:"elements" protobuf:"bytes,1,name=elements"`
+	Template     ApplicationSetTemplate `json:"template,omitempty" protobuf:"bytes,2,name=template"`
+	ElementsYaml string                 `json:"elementsYaml,omitempty" protobuf:"bytes,3,opt,name=elementsYaml"`
+}
+
 // ClusterGenerator defines a generator to match against clusters registered with ArgoCD.
 type ClusterGenerator struct {
 	// Selector defines a label selector to match against all clusters registered with ArgoCD.
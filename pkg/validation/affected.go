@@ -0,0 +1,119 @@
+package validation
+
+import (
+	iofs "io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// AffectedPaths returns the kustomization directories and the Application files under the given roots (relative to
+// the base directory) which are affected by the given changed files or directories: the kustomization directories
+// which contain or (transitively) reference a changed path, and the Application files which changed or whose source
+// path contains a changed path. The result can be used as the `Paths` option to only check these paths.
+func AffectedPaths(logger Logger, afs afero.Afero, baseDir string, roots []string, changed ...string) (map[string]bool, error) {
+	deps := map[string][]string{}
+	sources := map[string][]string{}
+	for _, root := range roots {
+		if err := afs.Walk(filepath.Join(baseDir, root), func(path string, info iofs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if _, found := lookupKustomizationFile(logger, afs, path); found {
+					deps[path] = nil
+				}
+				return nil
+			}
+			if filepath.Ext(path) != ".yaml" {
+				return nil
+			}
+			data, err := afs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			objs, err := readObjects(data)
+			if err != nil {
+				return nil
+			}
+			for _, obj := range objs {
+				if s, err := applicationSources(obj); err == nil {
+					for _, src := range s {
+						sources[path] = append(sources[path], filepath.Join(baseDir, src.Path))
+					}
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	affected := map[string]bool{}
+	for dir := range deps {
+		for _, dep := range kustomizationDependencies(logger, afs, dir, map[string]bool{}) {
+			if overlaps(dep, changed...) {
+				logger.Debug("kustomization is affected by changes", "path", dir, "dependency", dep)
+				affected[dir] = true
+				break
+			}
+		}
+	}
+	for path, srcs := range sources {
+		if overlaps(path, changed...) {
+			affected[path] = true
+			continue
+		}
+		for _, src := range srcs {
+			if overlaps(src, changed...) {
+				logger.Debug("Application is affected by changes", "path", path, "source", src)
+				affected[path] = true
+				break
+			}
+		}
+	}
+	return affected, nil
+}
+
+// returns the given kustomization directory along with the local files and directories it references, including the
+// references of the referenced kustomizations
+func kustomizationDependencies(logger Logger, afs afero.Afero, dir string, visited map[string]bool) []string {
+	if visited[dir] {
+		return nil
+	}
+	visited[dir] = true
+	deps := []string{dir}
+	kp, found := lookupKustomizationFile(logger, afs, dir)
+	if !found {
+		return deps
+	}
+	data, err := afs.ReadFile(kp)
+	if err != nil {
+		return deps
+	}
+	var kobj types.Kustomization
+	if err := kobj.Unmarshal(data); err != nil {
+		return deps
+	}
+	for _, ref := range kustomizationReferences(kobj) {
+		p := filepath.Join(dir, ref.path)
+		if isDir, _ := afs.IsDir(p); isDir {
+			deps = append(deps, kustomizationDependencies(logger, afs, p, visited)...)
+			continue
+		}
+		deps = append(deps, p)
+	}
+	return deps
+}
+
+// returns true if the given path is one of the changed paths, or is a parent or a child of one of them
+func overlaps(path string, changed ...string) bool {
+	for _, c := range changed {
+		c = filepath.Clean(c)
+		if c == path || strings.HasPrefix(c, path+string(filepath.Separator)) || strings.HasPrefix(path, c+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAffectedPaths(t *testing.T) {
+
+	logger := NewTestLogger(os.Stdout, charmlog.Options{
+		Level: charmlog.InfoLevel,
+	})
+	afs := afero.Afero{
+		Fs: afero.NewMemMapFs(),
+	}
+	err := addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  source:
+    path: components/cookie/overlay`)
+	require.NoError(t, err)
+	err = addFile(afs, "/path/to/apps/pasta.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: pasta
+spec:
+  source:
+    path: components/pasta`)
+	require.NoError(t, err)
+	err = addFile(afs, "/path/to/components/cookie/overlay/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- ../../../shared/base
+patches:
+- path: patch.yaml`)
+	require.NoError(t, err)
+	err = addFile(afs, "/path/to/components/cookie/overlay/patch.yaml", `kind: ConfigMap`)
+	require.NoError(t, err)
+	err = addFile(afs, "/path/to/shared/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+	require.NoError(t, err)
+	err = addFile(afs, "/path/to/shared/base/configmap.yaml", `kind: ConfigMap`)
+	require.NoError(t, err)
+	err = addFile(afs, "/path/to/components/pasta/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+	require.NoError(t, err)
+	err = addFile(afs, "/path/to/components/pasta/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: pasta
+data:
+  pasta: yummy`)
+	require.NoError(t, err)
+	roots := []string{"apps", "components"}
+
+	t.Run("file in overlay", func(t *testing.T) {
+		// when
+		affected, err := validation.AffectedPaths(logger, afs, "/path/to", roots, "/path/to/components/cookie/overlay/patch.yaml")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{
+			"/path/to/components/cookie/overlay": true,
+			"/path/to/apps/cookie.yaml":          true,
+		}, affected)
+	})
+
+	t.Run("file in referenced base", func(t *testing.T) {
+		// when
+		affected, err := validation.AffectedPaths(logger, afs, "/path/to", roots, "/path/to/shared/base/configmap.yaml")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{
+			"/path/to/components/cookie/overlay": true,
+		}, affected)
+	})
+
+	t.Run("application file", func(t *testing.T) {
+		// when
+		affected, err := validation.AffectedPaths(logger, afs, "/path/to", roots, "/path/to/apps/pasta.yaml")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{
+			"/path/to/apps/pasta.yaml": true,
+		}, affected)
+	})
+
+	t.Run("removed directory", func(t *testing.T) {
+		// when
+		affected, err := validation.AffectedPaths(logger, afs, "/path/to", roots, "/path/to/components")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{
+			"/path/to/components/cookie/overlay": true,
+			"/path/to/components/pasta":          true,
+			"/path/to/apps/cookie.yaml":          true,
+			"/path/to/apps/pasta.yaml":           true,
+		}, affected)
+	})
+
+	t.Run("unrelated file", func(t *testing.T) {
+		// when
+		affected, err := validation.AffectedPaths(logger, afs, "/path/to", roots, "/path/to/README.md")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, affected)
+	})
+
+	t.Run("check affected paths only", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		// the cookie overlay is not checked (its patch is invalid)
+		opts := validation.Options{
+			Paths: map[string]bool{
+				"/path/to/components/pasta": true,
+			},
+		}
+
+		// when
+		err := validation.CheckComponents(logger, afs, opts, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+	})
+}
@@ -0,0 +1,194 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	kvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// ArgoCDAnnotationPrefix is the prefix of the annotations handled by Argo CD
+	ArgoCDAnnotationPrefix = "argocd.argoproj.io/"
+	// SyncOptionsAnnotation is the annotation with the sync options of an object
+	SyncOptionsAnnotation = "argocd.argoproj.io/sync-options"
+	// CompareOptionsAnnotation is the annotation with the compare options of an object
+	CompareOptionsAnnotation = "argocd.argoproj.io/compare-options"
+	// ManagedByAnnotation is the annotation with the namespace of the Argo CD instance which manages a Namespace
+	ManagedByAnnotation = "argocd.argoproj.io/managed-by"
+	// TrackingIDAnnotation is the annotation set by Argo CD to track the objects of an Application
+	TrackingIDAnnotation = "argocd.argoproj.io/tracking-id"
+)
+
+// the annotations known by Argo CD
+var argocdAnnotations = []string{
+	SyncWaveAnnotation,
+	HookAnnotation,
+	HookDeletePolicyAnnotation,
+	SyncOptionsAnnotation,
+	CompareOptionsAnnotation,
+	ManagedByAnnotation,
+	TrackingIDAnnotation,
+	"argocd.argoproj.io/refresh",
+	"argocd.argoproj.io/manifest-generate-paths",
+	"argocd.argoproj.io/skip-reconcile",
+	"argocd.argoproj.io/application-set-refresh",
+}
+
+// the sync options which can be set on an object
+var syncOptions = []string{
+	"Prune=false",
+	"Prune=confirm",
+	"Delete=false",
+	"Delete=confirm",
+	"Validate=false",
+	"Validate=true",
+	"SkipDryRunOnMissingResource=true",
+	"Replace=true",
+	"ServerSideApply=true",
+	"ServerSideApply=false",
+	"Force=true",
+	"PruneLast=true",
+}
+
+// the compare options which can be set on an object
+var compareOptions = []string{
+	"IgnoreExtraneous",
+	"ServerSideDiff=true",
+	"ServerSideDiff=false",
+	"IncludeMutationWebhook=true",
+}
+
+// `<application>:<group>/<kind>:<namespace>/<name>`
+var trackingIDRegexp = regexp.MustCompile(`^[^:/]+:[^:/]*/[^:/]+:[^:/]*/[^:/]+$`)
+
+// verifies the keys and values of the Argo CD annotations of the rendered objects
+func checkArgoCDAnnotations(r *reporter, path string, objs []*yaml.RNode) {
+	for _, obj := range objs {
+		annotations := obj.GetAnnotations()
+		for _, key := range sortedKeys(annotations) {
+			value := annotations[key]
+			if !strings.HasPrefix(key, ArgoCDAnnotationPrefix) {
+				continue
+			}
+			keyVals := []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "annotation", key}
+			switch key {
+			case SyncOptionsAnnotation:
+				checkAnnotationOptions(r, path, key, value, syncOptions, "unknown sync option", keyVals)
+			case CompareOptionsAnnotation:
+				checkAnnotationOptions(r, path, key, value, compareOptions, "unknown compare option", keyVals)
+			case ManagedByAnnotation:
+				if errs := kvalidation.IsDNS1123Label(value); len(errs) > 0 {
+					r.report(InvalidArgoCDAnnotationRule, Finding{
+						Path:    path,
+						Message: "invalid Argo CD namespace",
+						KeyVals: append(keyVals, "value", value, "err", strings.Join(errs, ", ")),
+					})
+				}
+			case TrackingIDAnnotation:
+				if !trackingIDRegexp.MatchString(value) {
+					r.report(InvalidArgoCDAnnotationRule, Finding{
+						Path:       path,
+						Message:    "invalid tracking id",
+						KeyVals:    append(keyVals, "value", value),
+						Suggestion: fmt.Sprintf("%s: <application>:<group>/<kind>:<namespace>/<name>", key),
+					})
+				}
+			default:
+				if contains(argocdAnnotations, key) {
+					continue
+				}
+				f := Finding{
+					Path:    path,
+					Message: "unknown Argo CD annotation",
+					KeyVals: keyVals,
+				}
+				if s, found := closest(key, argocdAnnotations); found {
+					f.Suggestion = fmt.Sprintf("%s: %s", s, value)
+				}
+				r.report(InvalidArgoCDAnnotationRule, f)
+			}
+		}
+	}
+}
+
+// reports the comma-separated options of the given annotation which are not in the known options, with the closest
+// known option as a suggestion
+func checkAnnotationOptions(r *reporter, path, key, value string, known []string, msg string, keyVals []interface{}) {
+	options := strings.Split(value, ",")
+	for i, o := range options {
+		options[i] = strings.TrimSpace(o)
+	}
+	for i, o := range options {
+		if contains(known, o) {
+			continue
+		}
+		f := Finding{
+			Path:    path,
+			Message: msg,
+			KeyVals: append(append([]interface{}{}, keyVals...), "option", o),
+		}
+		if s, found := closest(o, known); found {
+			fixed := append([]string{}, options...)
+			fixed[i] = s
+			f.Suggestion = fmt.Sprintf("%s: %s", key, strings.Join(fixed, ","))
+		}
+		r.report(InvalidArgoCDAnnotationRule, f)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the candidate which is the closest to the given value (case-insensitive), if it is close enough to be
+// a misspelling
+func closest(value string, candidates []string) (string, bool) {
+	best, bestDistance := "", -1
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(value), strings.ToLower(c))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best, bestDistance >= 0 && bestDistance <= 3
+}
+
+// returns the edit distance between the given strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minOf(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(rb)]
+}
+
+func minOf(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
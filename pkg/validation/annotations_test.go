@@ -0,0 +1,132 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckArgoCDAnnotations(t *testing.T) {
+
+	newFS := func(t *testing.T, annotations string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+  namespace: cookie
+  annotations:
+`+annotations)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `    argocd.argoproj.io/sync-options: Prune=false, SkipDryRunOnMissingResource=true
+    argocd.argoproj.io/compare-options: IgnoreExtraneous
+    argocd.argoproj.io/managed-by: argocd
+    argocd.argoproj.io/tracking-id: cookie:/ConfigMap:cookie/cookie
+    example.com/sync-options: anything`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("misspelled sync options", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `    argocd.argoproj.io/sync-options: Prune=flase,SkipDryRunOnMissingResource=ture`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 2 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "unknown sync option",
+					KeyVals: []interface{}{
+						"rule", "ACK018",
+						"path", "/path/to/components/cookie",
+						"kind", "ConfigMap",
+						"name", "cookie",
+						"annotation", "argocd.argoproj.io/sync-options",
+						"option", "Prune=flase",
+						"suggestion", "argocd.argoproj.io/sync-options: Prune=false,SkipDryRunOnMissingResource=ture",
+					},
+				},
+				{
+					Msg: "unknown sync option",
+					KeyVals: []interface{}{
+						"rule", "ACK018",
+						"path", "/path/to/components/cookie",
+						"kind", "ConfigMap",
+						"name", "cookie",
+						"annotation", "argocd.argoproj.io/sync-options",
+						"option", "SkipDryRunOnMissingResource=ture",
+						"suggestion", "argocd.argoproj.io/sync-options: Prune=flase,SkipDryRunOnMissingResource=true",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("unknown annotation and invalid values", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `    argocd.argoproj.io/sync-option: Prune=false
+    argocd.argoproj.io/compare-options: IgnoreExtraneus
+    argocd.argoproj.io/managed-by: Argo_CD
+    argocd.argoproj.io/tracking-id: cookie`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 4 error(s)")
+			require.Len(t, logger.Errors(), 4)
+			assert.Equal(t, "unknown compare option", logger.Errors()[0].Msg)
+			assert.Contains(t, logger.Errors()[0].KeyVals, "argocd.argoproj.io/compare-options: IgnoreExtraneous")
+			assert.Equal(t, "invalid Argo CD namespace", logger.Errors()[1].Msg)
+			assert.Equal(t, LogRecord{
+				Msg: "unknown Argo CD annotation",
+				KeyVals: []interface{}{
+					"rule", "ACK018",
+					"path", "/path/to/components/cookie",
+					"kind", "ConfigMap",
+					"name", "cookie",
+					"annotation", "argocd.argoproj.io/sync-option",
+					"suggestion", "argocd.argoproj.io/sync-options: Prune=false",
+				},
+			}, logger.Errors()[2])
+			assert.Equal(t, "invalid tracking id", logger.Errors()[3].Msg)
+		})
+	})
+}
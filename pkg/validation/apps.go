@@ -1,20 +1,51 @@
 package validation
 
 import (
+	"encoding/json"
 	"fmt"
 	iofs "io/fs"
 	"path/filepath"
+	"strings"
 
 	argocdv1alpha1 "github.com/codeready-toolchain/argocd-checker/pkg/argocd-types/application/v1alpha1"
 
 	"github.com/spf13/afero"
+	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 // Look for all YAML files in the given paths and when the contents if an Argo CD Application or ApplicationSet,
-// verify that the `spec.source.path` matches an existing component
-func CheckApplications(logger Logger, afs afero.Afero, baseDir string, apps ...string) error {
+// verify that the `spec.source.path` matches an existing component, that the metadata is valid (name, namespace,
+// finalizers and unique names across all the given paths), and that the destination is a known cluster
+func CheckApplications(logger Logger, afs afero.Afero, opts Options, baseDir string, apps ...string) error {
+	r := newReporter(logger, opts)
+	if err := checkApplicationsIn(logger, r, afs, opts, baseDir, apps...); err != nil {
+		return err
+	}
+	return r.err()
+}
 
+// checks the Applications and ApplicationSets in the given paths, and reports the findings with the given reporter
+func checkApplicationsIn(logger Logger, r *reporter, afs afero.Afero, opts Options, baseDir string, apps ...string) error {
+	names := applicationNames{}
+	var baseFS kfsys.FileSystem
+	if opts.clusterKubeVersions() {
+		// the sources of the Applications are rendered to verify their APIs against the versions of the clusters
+		fs, err := NewInMemoryFS(logger, afs, baseDir)
+		if err != nil {
+			return err
+		}
+		if err := resolveRemoteResources(logger, afs, fs, opts, baseDir); err != nil {
+			return err
+		}
+		if err := resolveHelmCharts(logger, afs, fs, opts, baseDir); err != nil {
+			return err
+		}
+		if err := resolveSOPS(logger, fs, opts, baseDir); err != nil {
+			return err
+		}
+		baseFS = fs
+	}
 	for _, path := range apps {
 		p := filepath.Join(baseDir, path)
 		logger.Info("👀 checking Applications and ApplicationSets", "path", p)
@@ -22,38 +53,62 @@ func CheckApplications(logger Logger, afs afero.Afero, baseDir string, apps ...s
 		if err != nil {
 			return err
 		}
+		if err := resolveRemoteResources(logger, afs, fsys, opts, p); err != nil {
+			return err
+		}
+		if err := resolveHelmCharts(logger, afs, fsys, opts, p); err != nil {
+			return err
+		}
+		if err := resolveSOPS(logger, fsys, opts, p); err != nil {
+			return err
+		}
 		if err := afs.Walk(p, func(path string, info iofs.FileInfo, err error) error {
 			if err != nil {
 				logger.Error("prevent panic by handling failure", "path", path)
 				return err
 			}
-			if info.IsDir() {
-				logger.Debug("👀 checking contents", "path", path)
-				if kp, found := lookupKustomizationFile(logger, afs, path); found {
-					if err := checkKustomizeResources(logger, afs, kp); err != nil {
-						return err
-					}
-					if info.Name() != "base" {
-						if err := checkBuild(logger, fsys, path); err != nil {
-							return err
-						}
-					}
+			if opts.excluded(baseDir, path) {
+				logger.Debug("skipping excluded path", "path", path)
+				if info.IsDir() {
+					return filepath.SkipDir
 				}
 				return nil
 			}
+			if !opts.selected(path) {
+				return nil
+			}
+			if info.IsDir() {
+				logger.Debug("👀 checking contents", "path", path)
+				return checkKustomization(logger, r, afs, fsys, opts, path)
+			}
 			data, err := afs.ReadFile(path)
 			if err != nil {
 				return err
 			}
 			if filepath.Ext(info.Name()) == ".yaml" {
 				logger.Debug("checking contents", "path", path)
-				app := &argocdv1alpha1.Application{}
-				if err := yaml.Unmarshal(data, app); err != nil {
-					return checkPath(logger, afs, baseDir, app.Spec.Source.Path)
+				objs, err := readObjects(data)
+				if err != nil {
+					logger.Debug("skipping invalid YAML file", "path", path, "err", err)
+					return nil
+				}
+				appObjs := []*yaml.RNode{}
+				for _, obj := range objs {
+					sources, err := applicationSources(obj)
+					if err != nil {
+						return fmt.Errorf("invalid %s in %s: %w", obj.GetKind(), path, err)
+					}
+					if sources == nil {
+						// not an Application or ApplicationSet
+						continue
+					}
+					appObjs = append(appObjs, obj)
+					if err := checkApplication(logger, r, afs, baseFS, opts, names, baseDir, path, obj, sources); err != nil {
+						return err
+					}
 				}
-				appSet := &argocdv1alpha1.ApplicationSet{}
-				if err := yaml.Unmarshal(data, appSet); err != nil {
-					return checkPath(logger, afs, baseDir, appSet.Spec.Template.Spec.Source.Path)
+				if len(appObjs) > 0 {
+					return r.checkApplications(path, appObjs)
 				}
 			}
 			return nil
@@ -64,6 +119,74 @@ func CheckApplications(logger Logger, afs afero.Afero, baseDir string, apps ...s
 	return nil
 }
 
+// verifies the metadata, the destination and the source paths of the given Application or ApplicationSet,
+// declared (or rendered) in the given path. The APIs of the rendered sources of the Applications are verified against
+// the Kubernetes version of their destination cluster, if the given filesystem (of the base directory) is not nil.
+func checkApplication(logger Logger, r *reporter, afs afero.Afero, fsys kfsys.FileSystem, opts Options, names applicationNames, baseDir, path string, obj *yaml.RNode, sources argocdv1alpha1.ApplicationSources) error {
+	if err := checkApplicationMetadata(logger, r, opts, names, path, obj); err != nil {
+		return err
+	}
+	if err := checkDestination(logger, r, opts, path, obj); err != nil {
+		return err
+	}
+	for _, s := range sources {
+		if strings.Contains(s.Path, "{{") {
+			// templated path of an ApplicationSet, resolved by Argo CD
+			logger.Debug("skipping templated source path", "path", path, "name", obj.GetName(), "source", s.Path)
+			continue
+		}
+		if err := checkPath(logger, afs, baseDir, s.Path); err != nil {
+			r.report(InvalidSourcePathRule, Finding{
+				Path:    path,
+				Message: "invalid source path",
+				KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "source", s.Path},
+			})
+		}
+	}
+	if fsys != nil {
+		return checkApplicationAPIs(logger, r, afs, fsys, opts, baseDir, path, obj, sources)
+	}
+	return nil
+}
+
+// returns the sources of the given object if it is an Argo CD Application or ApplicationSet, nil otherwise
+func applicationSources(obj *yaml.RNode) (argocdv1alpha1.ApplicationSources, error) {
+	if !strings.HasPrefix(obj.GetApiVersion(), "argoproj.io/") {
+		return nil, nil
+	}
+	var spec argocdv1alpha1.ApplicationSpec
+	switch obj.GetKind() {
+	case "Application":
+		app := &argocdv1alpha1.Application{}
+		if err := decode(obj, app); err != nil {
+			return nil, err
+		}
+		spec = app.Spec
+	case "ApplicationSet":
+		appSet := &argocdv1alpha1.ApplicationSet{}
+		if err := decode(obj, appSet); err != nil {
+			return nil, err
+		}
+		spec = appSet.Spec.Template.Spec
+	default:
+		return nil, nil
+	}
+	sources := argocdv1alpha1.ApplicationSources{}
+	if spec.Source != nil {
+		sources = append(sources, *spec.Source)
+	}
+	return append(sources, spec.Sources...), nil
+}
+
+// decodes the given object into the target, using its JSON tags
+func decode(obj *yaml.RNode, target interface{}) error {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
 func checkPath(_ Logger, afs afero.Afero, repoURL, path string) error {
 	p := filepath.Join(repoURL, path)
 	if _, err := afs.ReadDir(p); err != nil {
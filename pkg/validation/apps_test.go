@@ -28,7 +28,7 @@ func TestCheckApplications(t *testing.T) {
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckApplications(logger, afs, "/path/to", "apps")
+			err = validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
 
 			// then
 			require.NoError(t, err)
@@ -53,11 +53,12 @@ apiVersion: kustomize.config.k8s.io/v1beta1`)
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckApplications(logger, afs, "/path/to", "apps")
+			err = validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
 
 			// then
-			require.Error(t, err, "kustomization.yaml is empty")
-			assert.Empty(t, logger.Errors())
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Errors(), 1)
+			assert.Equal(t, "kustomize build failed", logger.Errors()[0].Msg)
 			assert.Empty(t, logger.Warnings())
 		})
 
@@ -97,7 +98,7 @@ data:
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckApplications(logger, afs, "/path/to", "apps")
+			err = validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
 
 			// then
 			require.NoError(t, err)
@@ -137,7 +138,7 @@ data:
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckApplications(logger, afs, "/path/to", "apps")
+			err = validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
 
 			// then
 			require.NoError(t, err)
@@ -146,6 +147,87 @@ data:
 		})
 	})
 
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("application with invalid path", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    repoURL: https://github.com/org/repo
+    path: components/cookie`)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Contains(t, logger.Errors(), LogRecord{
+				Msg: "invalid source path",
+				KeyVals: []interface{}{
+					"rule", "ACK003",
+					"path", "/path/to/apps/cookie.yaml",
+					"kind", "Application",
+					"name", "cookie",
+					"source", "components/cookie",
+				},
+			})
+		})
+
+		t.Run("applicationset with invalid path", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: cookie
+spec:
+  generators: []
+  template:
+    metadata:
+      name: cookie
+    spec:
+      destination:
+        server: https://kubernetes.default.svc
+      sources:
+      - repoURL: https://github.com/org/repo
+        path: components/cookie`)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Contains(t, logger.Errors(), LogRecord{
+				Msg: "invalid source path",
+				KeyVals: []interface{}{
+					"rule", "ACK003",
+					"path", "/path/to/apps/cookie.yaml",
+					"kind", "ApplicationSet",
+					"name", "cookie",
+					"source", "components/cookie",
+				},
+			})
+		})
+	})
 }
 
 func addFile(afs afero.Afero, path string, data string) error {
@@ -0,0 +1,52 @@
+package validation
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+	kbuild "sigs.k8s.io/kustomize/kustomize/v5/commands/build"
+	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// BuildOptionsKey is the key of the `kustomize build` options in the `argocd-cm` ConfigMap
+const BuildOptionsKey = "kustomize.buildOptions"
+
+// ParseBuildOptions parses the given `kustomize build` options (eg: `--enable-helm --load-restrictor LoadRestrictionsNone`),
+// and rejects the options which are unknown, or which would not be honored by Argo CD (eg: `--output`, since
+// Argo CD reads the manifests from the standard output of `kustomize build`)
+func ParseBuildOptions(options string) ([]string, error) {
+	args := strings.Fields(options)
+	kcmd := kbuild.NewCmdBuild(kfsys.MakeFsInMemory(), &kbuild.Help{}, &bytes.Buffer{})
+	if err := kcmd.Flags().Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid kustomize build options '%s': %w", options, err)
+	}
+	if len(kcmd.Flags().Args()) > 0 {
+		return nil, fmt.Errorf("invalid kustomize build options '%s': unexpected arguments %v", options, kcmd.Flags().Args())
+	}
+	if kcmd.Flags().Changed("output") {
+		return nil, fmt.Errorf("invalid kustomize build options '%s': '--output' is not honored by Argo CD", options)
+	}
+	return args, nil
+}
+
+// LoadBuildOptions reads and parses the `kustomize build` options from the given `argocd-cm` ConfigMap file
+func LoadBuildOptions(afs afero.Afero, path string) ([]string, error) {
+	data, err := afs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cm := struct {
+		Kind string            `json:"kind"`
+		Data map[string]string `json:"data"`
+	}{}
+	if err := k8syaml.Unmarshal(data, &cm); err != nil {
+		return nil, fmt.Errorf("invalid ConfigMap %s: %w", path, err)
+	}
+	if cm.Kind != "ConfigMap" {
+		return nil, fmt.Errorf("invalid ConfigMap %s: unexpected kind '%s'", path, cm.Kind)
+	}
+	return ParseBuildOptions(cm.Data[BuildOptionsKey])
+}
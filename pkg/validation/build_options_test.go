@@ -0,0 +1,139 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBuildOptions(t *testing.T) {
+
+	t.Run("success", func(t *testing.T) {
+		// when
+		opts, err := validation.ParseBuildOptions("--enable-helm --load-restrictor LoadRestrictionsNone --enable-alpha-plugins")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"--enable-helm", "--load-restrictor", "LoadRestrictionsNone", "--enable-alpha-plugins"}, opts)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		// when
+		opts, err := validation.ParseBuildOptions("")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, opts)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("unknown flag", func(t *testing.T) {
+			// when
+			_, err := validation.ParseBuildOptions("--enable-cookies")
+
+			// then
+			require.EqualError(t, err, "invalid kustomize build options '--enable-cookies': unknown flag: --enable-cookies")
+		})
+
+		t.Run("output", func(t *testing.T) {
+			// when
+			_, err := validation.ParseBuildOptions("--enable-helm -o /tmp/manifests")
+
+			// then
+			require.EqualError(t, err, "invalid kustomize build options '--enable-helm -o /tmp/manifests': '--output' is not honored by Argo CD")
+		})
+
+		t.Run("path", func(t *testing.T) {
+			// when
+			_, err := validation.ParseBuildOptions("--enable-helm /path/to/overlay")
+
+			// then
+			require.EqualError(t, err, "invalid kustomize build options '--enable-helm /path/to/overlay': unexpected arguments [/path/to/overlay]")
+		})
+	})
+}
+
+func TestLoadBuildOptions(t *testing.T) {
+	// given
+	afs := afero.Afero{
+		Fs: afero.NewMemMapFs(),
+	}
+	err := addFile(afs, "/path/to/argocd-cm.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: argocd-cm
+  namespace: argocd
+data:
+  kustomize.buildOptions: --load-restrictor LoadRestrictionsNone`)
+	require.NoError(t, err)
+
+	// when
+	opts, err := validation.LoadBuildOptions(afs, "/path/to/argocd-cm.yaml")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--load-restrictor", "LoadRestrictionsNone"}, opts)
+}
+
+func TestCheckBuildWithOptions(t *testing.T) {
+
+	newFS := func(t *testing.T) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		// the overlay references a file outside of its directory, which requires `--load-restrictor LoadRestrictionsNone`
+		err := addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- ../configmap.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+data:
+  cookie: yummy`)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("default options", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+		// then
+		require.EqualError(t, err, "found 1 error(s)")
+		require.Len(t, logger.Errors(), 1)
+		assert.Equal(t, "kustomize build failed", logger.Errors()[0].Msg)
+	})
+
+	t.Run("with load restrictor", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t)
+		opts, err := validation.ParseBuildOptions("--load-restrictor LoadRestrictionsNone")
+		require.NoError(t, err)
+
+		// when
+		err = validation.CheckComponents(logger, afs, validation.Options{BuildOptions: opts}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+	})
+}
@@ -0,0 +1,311 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+// Checker runs all the checks on the Applications, root Applications and components of a repository, and returns
+// the findings in a Report instead of a single error. A Checker is built with functional options:
+//
+//	report, err := validation.NewChecker(
+//		validation.WithBaseDir("/path/to/repo"),
+//		validation.WithApps("apps"),
+//		validation.WithComponents("components"),
+//		validation.WithKubeVersion("1.27"),
+//	).Run(ctx)
+type Checker struct {
+	afs         afero.Afero
+	logger      Logger
+	opts        Options
+	baseDir     string
+	apps        []string
+	rootApps    []string
+	components  []string
+	bases       []string
+	concurrency int
+}
+
+// CheckerOption configures a Checker
+type CheckerOption func(*Checker)
+
+// NewChecker returns a Checker configured with the given options. By default, the Checker reads the OS filesystem,
+// does not log anything, applies the built-in rules and checks one path at a time, from the current directory.
+func NewChecker(options ...CheckerOption) *Checker {
+	c := &Checker{
+		afs: afero.Afero{
+			Fs: afero.NewOsFs(),
+		},
+		logger:      nopLogger{},
+		baseDir:     ".",
+		concurrency: 1,
+	}
+	for _, o := range options {
+		o(c)
+	}
+	return c
+}
+
+// WithFs sets the filesystem of the repository
+func WithFs(fs afero.Fs) CheckerOption {
+	return func(c *Checker) {
+		c.afs = afero.Afero{Fs: fs}
+	}
+}
+
+// WithLogger sets the logger of the progress and of the findings. The logger must be safe for concurrent use if
+// the concurrency is greater than 1.
+func WithLogger(logger Logger) CheckerOption {
+	return func(c *Checker) {
+		c.logger = logger
+	}
+}
+
+// WithOptions sets the options of the checks. The other options of the Checker (eg: WithRules) override the
+// matching fields, regardless of their order.
+func WithOptions(opts Options) CheckerOption {
+	return func(c *Checker) {
+		rules, kubeVersion := c.opts.Rules, c.opts.KubeVersion
+		c.opts = opts
+		if rules != nil {
+			c.opts.Rules = rules
+		}
+		if kubeVersion != "" {
+			c.opts.KubeVersion = kubeVersion
+		}
+	}
+}
+
+// WithRules sets the rules to check, along with their settings
+func WithRules(rules *Registry) CheckerOption {
+	return func(c *Checker) {
+		c.opts.Rules = rules
+	}
+}
+
+// WithKubeVersion sets the Kubernetes version of the target clusters (eg: `1.27`)
+func WithKubeVersion(version string) CheckerOption {
+	return func(c *Checker) {
+		c.opts.KubeVersion = version
+	}
+}
+
+// WithConcurrency sets the maximum number of paths which are checked concurrently (1 if lower)
+func WithConcurrency(n int) CheckerOption {
+	return func(c *Checker) {
+		c.concurrency = n
+	}
+}
+
+// WithBaseDir sets the base directory of the repository
+func WithBaseDir(dir string) CheckerOption {
+	return func(c *Checker) {
+		c.baseDir = dir
+	}
+}
+
+// WithApps sets the paths or patterns of the Applications, relative to the base directory
+func WithApps(paths ...string) CheckerOption {
+	return func(c *Checker) {
+		c.apps = paths
+	}
+}
+
+// WithRootApps sets the paths or patterns of the root Applications of app-of-apps trees, relative to the base
+// directory
+func WithRootApps(paths ...string) CheckerOption {
+	return func(c *Checker) {
+		c.rootApps = paths
+	}
+}
+
+// WithComponents sets the paths or patterns of the components, relative to the base directory
+func WithComponents(paths ...string) CheckerOption {
+	return func(c *Checker) {
+		c.components = paths
+	}
+}
+
+// WithBases sets the paths of the kustomizations which are referenced by other kustomizations (eg: the bases of a
+// discovered Layout), relative to the base directory. Their resources and references are checked, but they are not
+// built on their own.
+func WithBases(paths ...string) CheckerOption {
+	return func(c *Checker) {
+		c.bases = paths
+	}
+}
+
+// Report holds the findings of a run of a Checker
+type Report struct {
+	// Findings are the findings of the enabled rules, in the order of the checked paths
+	Findings []Finding
+	// Applications are the app-of-apps trees of the root Applications
+	Applications []*ApplicationNode
+}
+
+// Errors returns the findings with the `error` severity
+func (r Report) Errors() []Finding {
+	return r.withSeverity(SeverityError)
+}
+
+// Warnings returns the findings with the `warning` severity
+func (r Report) Warnings() []Finding {
+	return r.withSeverity(SeverityWarning)
+}
+
+func (r Report) withSeverity(severity Severity) []Finding {
+	findings := []Finding{}
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// Err returns an error if some findings have the `error` severity
+func (r Report) Err() error {
+	if errs := len(r.Errors()); errs > 0 {
+		return fmt.Errorf("found %d error(s)", errs)
+	}
+	return nil
+}
+
+// Run checks the root Applications, the Applications and the components. The layout of the repository is
+// discovered if none of them was set. The returned error is about the checks which could not complete (eg: an
+// invalid file, or a cancelled context), while the issues are in the findings of the Report.
+func (c *Checker) Run(ctx context.Context) (Report, error) {
+	report := Report{
+		Findings: []Finding{},
+	}
+	apps, err := ExpandPaths(c.afs, c.baseDir, c.apps...)
+	if err != nil {
+		return report, err
+	}
+	rootApps, err := ExpandPaths(c.afs, c.baseDir, c.rootApps...)
+	if err != nil {
+		return report, err
+	}
+	components, err := ExpandPaths(c.afs, c.baseDir, c.components...)
+	if err != nil {
+		return report, err
+	}
+	bases := c.bases
+	if len(apps) == 0 && len(rootApps) == 0 && len(components) == 0 {
+		layout, err := Discover(c.logger, c.afs, c.opts, c.baseDir)
+		if err != nil {
+			return report, err
+		}
+		apps, components, bases = layout.Apps, layout.Components, layout.Bases
+	}
+
+	opts := c.opts
+	if opts.clusterKubeVersions() {
+		// the kustomizations deployed to clusters with a known Kubernetes version are only verified against it
+		opts.sources = newClusterSources()
+	}
+	// the Applications are checked together, since their names must be unique across all paths. They are checked
+	// before the components, which depend on the recorded sources of the Applications.
+	appTasks := []func(r *reporter) error{}
+	if len(rootApps) > 0 {
+		appTasks = append(appTasks, func(r *reporter) error {
+			nodes, err := checkApplicationTree(c.logger, r, c.afs, opts, c.baseDir, rootApps...)
+			report.Applications = nodes
+			return err
+		})
+	}
+	if len(apps) > 0 {
+		appTasks = append(appTasks, func(r *reporter) error {
+			return checkApplicationsIn(c.logger, r, c.afs, opts, c.baseDir, apps...)
+		})
+	}
+	componentTasks := []func(r *reporter) error{}
+	for _, path := range components {
+		path := path
+		componentTasks = append(componentTasks, func(r *reporter) error {
+			return checkComponentsIn(c.logger, r, c.afs, opts, c.baseDir, path)
+		})
+	}
+	// the bases which are not in the paths of the components or Applications are not checked otherwise
+	checked := map[string]bool{}
+	for _, p := range append(append([]string{}, apps...), components...) {
+		checked[filepath.Join(c.baseDir, p)] = true
+	}
+	for _, path := range bases {
+		path := path
+		if within(filepath.Join(c.baseDir, path), checked) {
+			continue
+		}
+		componentTasks = append(componentTasks, func(r *reporter) error {
+			return checkBasesIn(c.logger, r, c.afs, opts, c.baseDir, path)
+		})
+	}
+	for _, tasks := range [][]func(r *reporter) error{appTasks, componentTasks} {
+		findings, err := c.run(ctx, opts, tasks)
+		report.Findings = append(report.Findings, findings...)
+		if err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// runs the given tasks with the concurrency of the Checker, and returns their findings in the order of the tasks
+func (c *Checker) run(ctx context.Context, opts Options, tasks []func(r *reporter) error) ([]Finding, error) {
+	findings := make([][]Finding, len(tasks))
+	errs := make([]error, len(tasks))
+	concurrency := c.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, task := range tasks {
+		select {
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(i int, task func(r *reporter) error) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			r := newReporter(c.logger, opts)
+			errs[i] = task(r)
+			findings[i] = *r.findings
+		}(i, task)
+	}
+	wg.Wait()
+	all := []Finding{}
+	for i := range tasks {
+		all = append(all, findings[i]...)
+	}
+	for _, err := range errs {
+		if err != nil {
+			return all, err
+		}
+	}
+	return all, nil
+}
+
+// nopLogger discards all the logs
+type nopLogger struct{}
+
+var _ Logger = nopLogger{}
+
+func (nopLogger) Debug(interface{}, ...interface{}) {}
+func (nopLogger) Info(interface{}, ...interface{})  {}
+func (nopLogger) Warn(interface{}, ...interface{})  {}
+func (nopLogger) Error(interface{}, ...interface{}) {}
+func (nopLogger) Fatal(interface{}, ...interface{}) {}
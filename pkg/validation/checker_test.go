@@ -0,0 +1,231 @@
+package validation_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+
+	newFS := func(t *testing.T) afero.Fs {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`)
+		require.NoError(t, err)
+		for _, name := range []string{"cookie", "pasta", "pizza", "salad"} {
+			err := addFile(afs, fmt.Sprintf("/path/to/components/%s/kustomization.yaml", name), `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- cronjob.yaml`)
+			require.NoError(t, err)
+			err = addFile(afs, fmt.Sprintf("/path/to/components/%s/cronjob.yaml", name), `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: `+name+`
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: `+name+`
+            image: quay.io/org/`+name+`:latest`)
+			require.NoError(t, err)
+		}
+		return afs.Fs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		rules := validation.NewRegistry()
+		err := rules.SetEnabled("unpinned-image", false)
+		require.NoError(t, err)
+		checker := validation.NewChecker(
+			validation.WithFs(newFS(t)),
+			validation.WithBaseDir("/path/to"),
+			validation.WithApps("apps"),
+			validation.WithComponents("components/*"),
+			validation.WithRules(rules),
+		)
+
+		// when
+		report, err := checker.Run(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, report.Err())
+		assert.Empty(t, report.Findings)
+	})
+
+	t.Run("discovered layout", func(t *testing.T) {
+		// given
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/cookie/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/base/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie`)
+		require.NoError(t, err)
+		for _, env := range []string{"dev", "prod"} {
+			err = addFile(afs, "/path/to/components/cookie/overlays/"+env+"/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+namePrefix: `+env+`-
+resources:
+- ../../base`)
+			require.NoError(t, err)
+		}
+
+		t.Run("success", func(t *testing.T) {
+			// given
+			checker := validation.NewChecker(
+				validation.WithFs(afs.Fs),
+				validation.WithBaseDir("/path/to"),
+			)
+
+			// when
+			report, err := checker.Run(context.Background())
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, report.Findings)
+		})
+
+		t.Run("findings in base", func(t *testing.T) {
+			// given
+			err := addFile(afs, "/path/to/components/cookie/base/unused.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unused`)
+			require.NoError(t, err)
+			err = addFile(afs, "/path/to/components/cookie/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml
+configMapGenerator:
+- name: pasta
+  files:
+  - missing.properties`)
+			require.NoError(t, err)
+			checker := validation.NewChecker(
+				validation.WithFs(afs.Fs),
+				validation.WithBaseDir("/path/to"),
+			)
+
+			// when
+			report, err := checker.Run(context.Background())
+
+			// then
+			require.NoError(t, err)
+			ruleIDs := []string{}
+			for _, f := range report.Findings {
+				ruleIDs = append(ruleIDs, f.RuleID+" "+f.Path)
+			}
+			assert.ElementsMatch(t, []string{
+				// the overlays can't be built, since the file of the base is missing
+				"ACK002 /path/to/components/cookie/overlays/dev",
+				"ACK002 /path/to/components/cookie/overlays/prod",
+				"ACK001 /path/to/components/cookie/base/kustomization.yaml",
+				"ACK004 /path/to/components/cookie/base/kustomization.yaml",
+			}, ruleIDs)
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("findings", func(t *testing.T) {
+			// given
+			checker := validation.NewChecker(
+				validation.WithFs(newFS(t)),
+				validation.WithBaseDir("/path/to"),
+				validation.WithApps("apps"),
+				validation.WithComponents("components/*"),
+				validation.WithKubeVersion("1.25"),
+				validation.WithConcurrency(3),
+			)
+
+			// when
+			report, err := checker.Run(context.Background())
+
+			// then
+			require.NoError(t, err)
+			require.EqualError(t, report.Err(), "found 4 error(s)")
+			require.Len(t, report.Findings, 8)
+			require.Len(t, report.Warnings(), 4)
+			// findings are in the order of the paths, regardless of the concurrency
+			for i, name := range []string{"cookie", "pasta", "pizza", "salad"} {
+				assert.Equal(t, validation.Finding{
+					RuleID:     "ACK033",
+					Severity:   validation.SeverityError,
+					Path:       "/path/to/components/" + name,
+					Message:    "API was removed",
+					KeyVals:    []interface{}{"kind", "CronJob", "name", name, "apiVersion", "batch/v1beta1", "kubeVersion", "1.25", "removedIn", "1.25"},
+					Suggestion: "apiVersion: batch/v1",
+				}, report.Errors()[i])
+				assert.Equal(t, "ACK022", report.Warnings()[i].RuleID)
+				assert.Equal(t, "/path/to/components/"+name, report.Warnings()[i].Path)
+			}
+		})
+
+		t.Run("options override", func(t *testing.T) {
+			// given
+			checker := validation.NewChecker(
+				validation.WithKubeVersion("1.25"),
+				validation.WithFs(newFS(t)),
+				validation.WithOptions(validation.Options{
+					Strict: true,
+				}),
+				validation.WithBaseDir("/path/to"),
+				validation.WithComponents("components/cookie"),
+			)
+
+			// when
+			report, err := checker.Run(context.Background())
+
+			// then
+			require.NoError(t, err)
+			// the warning is an error in strict mode, and the kube version is not reset by the options
+			require.EqualError(t, report.Err(), "found 2 error(s)")
+			assert.Empty(t, report.Warnings())
+		})
+
+		t.Run("cancelled context", func(t *testing.T) {
+			// given
+			checker := validation.NewChecker(
+				validation.WithFs(newFS(t)),
+				validation.WithBaseDir("/path/to"),
+				validation.WithComponents("components/*"),
+			)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			// when
+			report, err := checker.Run(ctx)
+
+			// then
+			require.ErrorIs(t, err, context.Canceled)
+			assert.Empty(t, report.Findings)
+		})
+	})
+}
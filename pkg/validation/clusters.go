@@ -0,0 +1,260 @@
+package validation
+
+import (
+	"encoding/base64"
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"strings"
+
+	argocdv1alpha1 "github.com/codeready-toolchain/argocd-checker/pkg/argocd-types/application/v1alpha1"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+const (
+	// InClusterServer is the URL of the cluster in which Argo CD runs, which is always a known destination
+	InClusterServer = "https://kubernetes.default.svc"
+	// InClusterName is the name of the cluster in which Argo CD runs
+	InClusterName = "in-cluster"
+	// ClusterSecretTypeLabel is the label of the Secrets which declare the clusters managed by Argo CD
+	ClusterSecretTypeLabel = "argocd.argoproj.io/secret-type"
+)
+
+// Cluster is a destination cluster managed by Argo CD
+type Cluster struct {
+	// Name is the symbolic name of the cluster
+	Name string `json:"name,omitempty"`
+	// Server is the URL of the Kubernetes API server of the cluster
+	Server string `json:"server,omitempty"`
+	// KubeVersion is the Kubernetes version of the cluster (eg: `1.27`)
+	KubeVersion string `json:"kubeVersion,omitempty"`
+}
+
+// clustersFile is the content of a clusters file
+type clustersFile struct {
+	Clusters []Cluster `json:"clusters"`
+}
+
+// LoadClusters reads the clusters declared at the given path, which is either a clusters file:
+//
+//	clusters:
+//	- name: prod
+//	  server: https://prod.example.com:6443
+//	  kubeVersion: "1.27"
+//
+// or a YAML file or directory of Argo CD cluster Secrets (with the `argocd.argoproj.io/secret-type: cluster` label)
+func LoadClusters(afs afero.Afero, path string) ([]Cluster, error) {
+	isDir, err := afs.IsDir(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isDir {
+		return loadClustersFile(afs, path)
+	}
+	clusters := []Cluster{}
+	if err := afs.Walk(path, func(p string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(p) != ".yaml" {
+			return nil
+		}
+		data, err := afs.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		c, err := clusterSecrets(p, data)
+		if err != nil {
+			return err
+		}
+		clusters = append(clusters, c...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// reads the given file, which contains either a list of clusters or cluster Secrets
+func loadClustersFile(afs afero.Afero, path string) ([]Cluster, error) {
+	data, err := afs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if clusters, err := clusterSecrets(path, data); err != nil {
+		return nil, err
+	} else if len(clusters) > 0 {
+		return clusters, nil
+	}
+	f := clustersFile{}
+	if err := k8syaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid clusters file %s: %w", path, err)
+	}
+	for _, c := range f.Clusters {
+		if c.Name == "" && c.Server == "" {
+			return nil, fmt.Errorf("invalid clusters file %s: missing name or server", path)
+		}
+		if c.KubeVersion != "" && !kubeVersionRegexp.MatchString(c.KubeVersion) {
+			return nil, fmt.Errorf("invalid clusters file %s: invalid Kubernetes version '%s'", path, c.KubeVersion)
+		}
+	}
+	return f.Clusters, nil
+}
+
+// returns the clusters declared by the Argo CD cluster Secrets in the given data
+func clusterSecrets(path string, data []byte) ([]Cluster, error) {
+	objs, err := readObjects(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clusters file %s: %w", path, err)
+	}
+	clusters := []Cluster{}
+	for _, obj := range objs {
+		if obj.GetKind() != "Secret" || obj.GetLabels()[ClusterSecretTypeLabel] != "cluster" {
+			continue
+		}
+		c := Cluster{}
+		for field, target := range map[string]*string{"name": &c.Name, "server": &c.Server} {
+			v, err := secretValue(obj, field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cluster Secret '%s' in %s: %w", obj.GetName(), path, err)
+			}
+			*target = v
+		}
+		if c.Server == "" {
+			return nil, fmt.Errorf("invalid cluster Secret '%s' in %s: missing server", obj.GetName(), path)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
+
+// returns the value of the given key in the `stringData` or (base64-decoded) `data` of the given Secret
+func secretValue(obj *yaml.RNode, key string) (string, error) {
+	if v := obj.Field("stringData"); v != nil {
+		if value := v.Value.Field(key); value != nil {
+			return yaml.GetValue(value.Value), nil
+		}
+	}
+	if v := obj.Field("data"); v != nil {
+		if value := v.Value.Field(key); value != nil {
+			decoded, err := base64.StdEncoding.DecodeString(yaml.GetValue(value.Value))
+			if err != nil {
+				return "", fmt.Errorf("invalid '%s': %w", key, err)
+			}
+			return string(decoded), nil
+		}
+	}
+	return "", nil
+}
+
+// returns true if the given server URL or cluster name refers to the in-cluster target or one of the declared clusters
+func (o Options) knownDestination(dest argocdv1alpha1.ApplicationDestination) bool {
+	if dest.Server != "" {
+		if strings.TrimSuffix(dest.Server, "/") == InClusterServer {
+			return true
+		}
+		for _, c := range o.Clusters {
+			if strings.TrimSuffix(c.Server, "/") == strings.TrimSuffix(dest.Server, "/") {
+				return true
+			}
+		}
+		return false
+	}
+	if dest.Name == InClusterName {
+		return true
+	}
+	for _, c := range o.Clusters {
+		if c.Name == dest.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// verifies the destination of the given Application or ApplicationSet: either the server or the name must be set,
+// and it must refer to a declared cluster (if any). The destinations of the ApplicationSets are resolved with the
+// elements of their list generators, and the unresolved ones are only checked for the server/name exclusivity.
+func checkDestination(logger Logger, r *reporter, opts Options, path string, obj *yaml.RNode) error {
+	kind := obj.GetKind()
+	dests := []argocdv1alpha1.ApplicationDestination{}
+	switch kind {
+	case "Application":
+		app := &argocdv1alpha1.Application{}
+		if err := decode(obj, app); err != nil {
+			return err
+		}
+		dests = append(dests, app.Spec.Destination)
+	case "ApplicationSet":
+		appSet := &argocdv1alpha1.ApplicationSet{}
+		if err := decode(obj, appSet); err != nil {
+			return err
+		}
+		dest := appSet.Spec.Template.Spec.Destination
+		if !strings.Contains(dest.Server+dest.Name, "{{") {
+			dests = append(dests, dest)
+			break
+		}
+		for _, g := range appSet.Spec.Generators {
+			if g.List == nil {
+				continue
+			}
+			elements, err := listParams(g.List)
+			if err != nil {
+				return fmt.Errorf("invalid ApplicationSet in %s: %w", path, err)
+			}
+			for _, params := range elements {
+				server, serverResolved := resolveTemplate(dest.Server, params)
+				name, nameResolved := resolveTemplate(dest.Name, params)
+				if serverResolved && nameResolved {
+					dests = append(dests, argocdv1alpha1.ApplicationDestination{Server: server, Name: name, Namespace: dest.Namespace})
+				}
+			}
+		}
+		if len(dests) == 0 {
+			logger.Debug("skipping unresolved destination", "path", path, "applicationSet", obj.GetName(), "server", dest.Server, "destName", dest.Name)
+			checkDestinationFields(r, path, kind, obj.GetName(), dest)
+			return nil
+		}
+	default:
+		return nil
+	}
+	for _, dest := range dests {
+		if !checkDestinationFields(r, path, kind, obj.GetName(), dest) {
+			continue
+		}
+		if opts.Clusters != nil && !opts.knownDestination(dest) {
+			keyVals := []interface{}{"kind", kind, "name", obj.GetName(), "server", dest.Server}
+			if dest.Server == "" {
+				keyVals = []interface{}{"kind", kind, "name", obj.GetName(), "cluster", dest.Name}
+			}
+			r.report(UnknownDestinationRule, Finding{
+				Path:    path,
+				Message: "unknown destination cluster",
+				KeyVals: keyVals,
+			})
+		}
+	}
+	return nil
+}
+
+// reports the destination if both or neither the server and the name are set. Returns true if the destination is valid.
+func checkDestinationFields(r *reporter, path, kind, name string, dest argocdv1alpha1.ApplicationDestination) bool {
+	var msg string
+	switch {
+	case dest.Server == "" && dest.Name == "":
+		msg = "destination has neither a server nor a name"
+	case dest.Server != "" && dest.Name != "":
+		msg = "destination has both a server and a name"
+	default:
+		return true
+	}
+	r.report(InvalidDestinationRule, Finding{
+		Path:    path,
+		Message: msg,
+		KeyVals: []interface{}{"kind", kind, "name", name, "server", dest.Server, "cluster", dest.Name},
+	})
+	return false
+}
@@ -0,0 +1,370 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadClusters(t *testing.T) {
+
+	t.Run("clusters file", func(t *testing.T) {
+		// given
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/clusters.yaml", `clusters:
+- name: dev
+  server: https://dev.example.com:6443
+- name: prod
+  server: https://prod.example.com:6443
+  kubeVersion: "1.27"`)
+		require.NoError(t, err)
+
+		// when
+		clusters, err := validation.LoadClusters(afs, "/path/to/clusters.yaml")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []validation.Cluster{
+			{Name: "dev", Server: "https://dev.example.com:6443"},
+			{Name: "prod", Server: "https://prod.example.com:6443", KubeVersion: "1.27"},
+		}, clusters)
+	})
+
+	t.Run("directory of cluster secrets", func(t *testing.T) {
+		// given
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/clusters/dev.yaml", `apiVersion: v1
+kind: Secret
+metadata:
+  name: dev
+  labels:
+    argocd.argoproj.io/secret-type: cluster
+stringData:
+  name: dev
+  server: https://dev.example.com:6443`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/clusters/prod.yaml", `apiVersion: v1
+kind: Secret
+metadata:
+  name: prod
+  labels:
+    argocd.argoproj.io/secret-type: cluster
+data:
+  name: cHJvZA== # prod
+  server: aHR0cHM6Ly9wcm9kLmV4YW1wbGUuY29tOjY0NDM= # https://prod.example.com:6443
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: repo
+  labels:
+    argocd.argoproj.io/secret-type: repository
+stringData:
+  url: https://github.com/org/repo`)
+		require.NoError(t, err)
+
+		// when
+		clusters, err := validation.LoadClusters(afs, "/path/to/clusters")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []validation.Cluster{
+			{Name: "dev", Server: "https://dev.example.com:6443"},
+			{Name: "prod", Server: "https://prod.example.com:6443"},
+		}, clusters)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("unknown field", func(t *testing.T) {
+			// given
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/clusters.yaml", `clusters:
+- name: dev
+  url: https://dev.example.com:6443`)
+			require.NoError(t, err)
+
+			// when
+			_, err = validation.LoadClusters(afs, "/path/to/clusters.yaml")
+
+			// then
+			require.ErrorContains(t, err, "invalid clusters file /path/to/clusters.yaml")
+		})
+
+		t.Run("secret without server", func(t *testing.T) {
+			// given
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/clusters/dev.yaml", `apiVersion: v1
+kind: Secret
+metadata:
+  name: dev
+  labels:
+    argocd.argoproj.io/secret-type: cluster
+stringData:
+  name: dev`)
+			require.NoError(t, err)
+
+			// when
+			_, err = validation.LoadClusters(afs, "/path/to/clusters")
+
+			// then
+			require.EqualError(t, err, "invalid cluster Secret 'dev' in /path/to/clusters/dev.yaml: missing server")
+		})
+	})
+}
+
+func TestCheckDestinations(t *testing.T) {
+
+	newFS := func(t *testing.T, app string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := afs.MkdirAll("/path/to/components/cookie", 0755)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/apps/cookie.yaml", app)
+		require.NoError(t, err)
+		return afs
+	}
+	clusters := []validation.Cluster{
+		{Name: "dev", Server: "https://dev.example.com:6443"},
+	}
+
+	t.Run("success", func(t *testing.T) {
+
+		for name, dest := range map[string]string{
+			"in-cluster server": "server: https://kubernetes.default.svc",
+			"in-cluster name":   "name: in-cluster",
+			"declared server":   "server: https://dev.example.com:6443/",
+			"declared name":     "name: dev",
+		} {
+			t.Run(name, func(t *testing.T) {
+				// given
+				logger := NewTestLogger(os.Stdout, charmlog.Options{
+					Level: charmlog.InfoLevel,
+				})
+				afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    `+dest+`
+  source:
+    path: components/cookie`)
+
+				// when
+				err := validation.CheckApplications(logger, afs, validation.Options{Clusters: clusters}, "/path/to", "apps")
+
+				// then
+				require.NoError(t, err)
+				assert.Empty(t, logger.Errors())
+			})
+		}
+
+		t.Run("no declared clusters", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    name: prod
+  source:
+    path: components/cookie`)
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+		})
+
+		t.Run("unresolved applicationset destination", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: cookies
+spec:
+  generators:
+  - clusters: {}
+  template:
+    metadata:
+      name: 'cookie-{{name}}'
+    spec:
+      destination:
+        server: '{{server}}'
+      source:
+        path: components/cookie`)
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{Clusters: clusters}, "/path/to", "apps")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("neither server nor name", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    namespace: cookie
+  source:
+    path: components/cookie`)
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "destination has neither a server nor a name",
+					KeyVals: []interface{}{
+						"rule", "ACK012",
+						"path", "/path/to/apps/cookie.yaml",
+						"kind", "Application",
+						"name", "cookie",
+						"server", "",
+						"cluster", "",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("both server and name", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://dev.example.com:6443
+    name: dev
+  source:
+    path: components/cookie`)
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{Clusters: clusters}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Errors(), 1)
+			assert.Equal(t, "destination has both a server and a name", logger.Errors()[0].Msg)
+		})
+
+		t.Run("unknown server", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://prod.example.com:6443
+  source:
+    path: components/cookie`)
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{Clusters: clusters}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "unknown destination cluster",
+					KeyVals: []interface{}{
+						"rule", "ACK013",
+						"path", "/path/to/apps/cookie.yaml",
+						"kind", "Application",
+						"name", "cookie",
+						"server", "https://prod.example.com:6443",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("unknown generated cluster name", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: cookies
+spec:
+  generators:
+  - list:
+      elements:
+      - cluster: dev
+      - cluster: prod
+  template:
+    metadata:
+      name: 'cookie-{{cluster}}'
+    spec:
+      destination:
+        name: '{{cluster}}'
+      source:
+        path: components/cookie`)
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{Clusters: clusters}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "unknown destination cluster",
+					KeyVals: []interface{}{
+						"rule", "ACK013",
+						"path", "/path/to/apps/cookie.yaml",
+						"kind", "ApplicationSet",
+						"name", "cookies",
+						"cluster", "prod",
+					},
+				},
+			}, logger.Errors())
+		})
+	})
+}
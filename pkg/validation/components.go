@@ -5,44 +5,97 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/types"
 )
 
 // Looks for a `kustomization.yaml` file in all `components` directories and subdirs,
 // and attempt to run `kustomize build`
-func CheckComponents(logger Logger, afs afero.Afero, baseDir string, components ...string) error {
+func CheckComponents(logger Logger, afs afero.Afero, opts Options, baseDir string, components ...string) error {
+	r := newReporter(logger, opts)
+	if err := checkComponentsIn(logger, r, afs, opts, baseDir, components...); err != nil {
+		return err
+	}
+	return r.err()
+}
 
+// checks the components in the given paths, and reports the findings with the given reporter. The in-memory
+// filesystem contains the whole base directory, since the components may reference kustomizations outside of their
+// path (eg: `../../base`).
+func checkComponentsIn(logger Logger, r *reporter, afs afero.Afero, opts Options, baseDir string, components ...string) error {
+	if len(components) == 0 {
+		return nil
+	}
+	fsys, err := NewInMemoryFS(logger, afs, baseDir)
+	if err != nil {
+		return err
+	}
+	if err := resolveRemoteResources(logger, afs, fsys, opts, baseDir); err != nil {
+		return err
+	}
+	if err := resolveHelmCharts(logger, afs, fsys, opts, baseDir); err != nil {
+		return err
+	}
+	if err := resolveSOPS(logger, fsys, opts, baseDir); err != nil {
+		return err
+	}
 	for _, path := range components {
 		p := filepath.Join(baseDir, path)
 		logger.Info("👀 checking Components", "path", p)
-		fsys, err := NewInMemoryFS(logger, afs, p)
-		if err != nil {
-			return err
-		}
 		if err := afs.Walk(p, func(path string, d fs.FileInfo, err error) error {
 			if err != nil {
 				logger.Error("prevent panic by handling failure", "path", path)
 				return err
 			}
-			if !d.IsDir() {
+			if opts.excluded(baseDir, path) {
+				logger.Debug("skipping excluded path", "path", path)
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.IsDir() || !opts.selected(path) {
 				// skip
 				return nil
 			}
 			// look for a Kustomization file in the directory
-			if kp, found := lookupKustomizationFile(logger, afs, path); found {
-				if err := checkKustomizeResources(logger, afs, kp); err != nil {
-					return err
-				}
-				if d.Name() != "base" {
-					logger.Debug("checking Kustomization build ", "path", path)
-					if err := checkBuild(logger, fsys, path); err != nil {
-						return err
-					}
-				}
-			}
-			return nil
+			return checkKustomization(logger, r, afs, fsys, opts, path)
 		}); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// checks the resources and the references of the kustomizations in the given paths, which are referenced by other
+// kustomizations (eg: discovered bases), without building them
+func checkBasesIn(logger Logger, r *reporter, afs afero.Afero, opts Options, baseDir string, bases ...string) error {
+	for _, path := range bases {
+		p := filepath.Join(baseDir, path)
+		if opts.excluded(baseDir, p) || !opts.selected(p) {
+			continue
+		}
+		kp, found := lookupKustomizationFile(logger, afs, p)
+		if !found {
+			continue
+		}
+		logger.Info("👀 checking base", "path", p)
+		data, err := afs.ReadFile(kp)
+		if err != nil {
+			return err
+		}
+		ignored := ignoredRules(data)
+		r := r.ignoring(ignored...)
+		checkIgnoredRules(r, kp, ignored)
+		var kobj types.Kustomization
+		if err := kobj.Unmarshal(data); err != nil {
+			return err
+		}
+		if err := checkKustomizeResources(logger, r, afs, kp, kobj); err != nil {
+			return err
+		}
+		if err := checkKustomizeReferences(logger, r, afs, kp, kobj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package validation_test
 
 import (
 	"os"
+	"strings"
 	"testing"
 
 	charmlog "github.com/charmbracelet/log"
@@ -27,7 +28,7 @@ func TestCheckComponents(t *testing.T) {
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckComponents(logger, afs, "/path/to", "components")
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
 
 			// then
 			require.NoError(t, err)
@@ -50,10 +51,35 @@ apiVersion: kustomize.config.k8s.io/v1beta1`)
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckComponents(logger, afs, "/path/to", "components")
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
 
 			// then
-			require.Error(t, err, "kustomization.yaml is empty")
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Errors(), 1)
+			assert.Equal(t, "kustomize build failed", logger.Errors()[0].Msg)
+			assert.Empty(t, logger.Warnings())
+		})
+
+		t.Run("excluded empty component", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/components/experimental/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1`)
+			require.NoError(t, err)
+			opts := validation.Options{
+				Exclude: []string{"components/experimental"},
+			}
+
+			// when
+			err = validation.CheckComponents(logger, afs, opts, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
 			assert.Empty(t, logger.Errors())
 			assert.Empty(t, logger.Warnings())
 		})
@@ -95,8 +121,13 @@ metadata:
 data:
   pasta: yummy`)
 			require.NoError(t, err)
+			// the generated Secrets are covered by the `plaintext-secret` rule tests
+			rules := validation.NewRegistry()
+			err = rules.SetEnabled("plaintext-secret", false)
+			require.NoError(t, err)
+
 			// when
-			err = validation.CheckComponents(logger, afs, "/path/to", "components")
+			err = validation.CheckComponents(logger, afs, validation.Options{Rules: rules}, "/path/to", "components")
 
 			// then
 			require.NoError(t, err)
@@ -142,7 +173,7 @@ data:
   cookie: yummy`)
 			require.NoError(t, err)
 			// when
-			err = validation.CheckComponents(logger, afs, "/path/to", "components")
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
 
 			// then
 			require.NoError(t, err)
@@ -189,7 +220,7 @@ spec:
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckComponents(logger, afs, "/path/to", "components")
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
 
 			// then
 			require.NoError(t, err)
@@ -236,7 +267,7 @@ spec:
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckComponents(logger, afs, "/path/to", "components")
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
 
 			// then
 			require.NoError(t, err)
@@ -282,7 +313,82 @@ setRoleBindingSubjects: defaultOnly
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckComponents(logger, afs, "/path/to", "components")
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+
+		t.Run("component base with references in all fields", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/components/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- resources/deployment.yaml
+components:
+- ../../shared/component
+crds:
+- crds/crd.yaml
+openapi:
+  path: schema/openapi.yaml
+configurations:
+- configurations.yaml
+generators:
+- generator.yaml
+- |-
+  apiVersion: builtin
+  kind: ConfigMapGenerator
+  metadata:
+    name: inline
+validators:
+- validator.yaml
+replacements:
+- path: replacements/replacement.yaml
+patchesJson6902:
+- path: patches/json-patch.yaml
+  target:
+    kind: Deployment
+    name: test
+configMapGenerator:
+- name: cm
+  envs:
+  - cm.env
+secretGenerator:
+- name: secret
+  env: secret.env
+helmCharts:
+- name: minecraft
+  valuesFile: values/values.yaml`)
+			require.NoError(t, err)
+			for _, f := range []string{
+				"resources/deployment.yaml",
+				"../../shared/component/kustomization.yaml",
+				"crds/crd.yaml",
+				"schema/openapi.yaml",
+				"configurations.yaml",
+				"generator.yaml",
+				"validator.yaml",
+				"replacements/replacement.yaml",
+				"patches/json-patch.yaml",
+				"cm.env",
+				"secret.env",
+				"values/values.yaml",
+				"charts/minecraft/values.yaml",
+			} {
+				err = addFile(afs, "/path/to/components/base/"+f, `kind: Test`)
+				require.NoError(t, err)
+			}
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
 
 			// then
 			require.NoError(t, err)
@@ -316,20 +422,98 @@ data:
 			require.NoError(t, err)
 
 			// when
-			err = validation.CheckComponents(logger, afs, "/path/to", "components")
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
 
 			// then
-			require.Error(t, err, "kustomization.yaml is empty")
-			assert.Empty(t, logger.Errors())
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Errors(), 1)
+			assert.Equal(t, "kustomize build failed", logger.Errors()[0].Msg)
 			assert.Contains(t, logger.Warnings(), LogRecord{
 				Msg: "resource is not referenced",
 				KeyVals: []interface{}{
+					"rule",
+					"ACK001",
 					"path",
 					"/path/to/components/kustomization.yaml",
 					"resource",
 					"configmap.yaml",
+					"suggestion",
+					"resources:\n- configmap.yaml",
 				},
 			})
 		})
+
+		t.Run("component with unused resource in subdirectory", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/components/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- resources/configmap.yaml`)
+			require.NoError(t, err)
+			for _, f := range []string{
+				"resources/configmap.yaml",
+				"resources/unused.yaml",
+				"resources/_ignored.yaml",
+				"_ignored/configmap.yaml",
+			} {
+				err = addFile(afs, "/path/to/components/base/"+f, `kind: Test`)
+				require.NoError(t, err)
+			}
+			err = addFile(afs, "/path/to/components/base/nested/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap-1.yaml`)
+			require.NoError(t, err)
+			for _, f := range []string{"configmap-1.yaml", "configmap-2.yaml"} {
+				err = addFile(afs, "/path/to/components/base/nested/"+f, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: `+strings.TrimSuffix(f, ".yaml")+`
+data:
+  cookie: yummy`)
+				require.NoError(t, err)
+			}
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "resource is not referenced",
+					KeyVals: []interface{}{
+						"rule",
+						"ACK001",
+						"path",
+						"/path/to/components/base/kustomization.yaml",
+						"resource",
+						"resources/unused.yaml",
+						"suggestion",
+						"resources:\n- resources/unused.yaml",
+					},
+				},
+				{
+					Msg: "resource is not referenced",
+					KeyVals: []interface{}{
+						"rule",
+						"ACK001",
+						"path",
+						"/path/to/components/base/nested/kustomization.yaml",
+						"resource",
+						"configmap-2.yaml",
+						"suggestion",
+						"resources:\n- configmap-2.yaml",
+					},
+				},
+			}, logger.Warnings())
+		})
 	})
 }
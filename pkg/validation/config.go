@@ -0,0 +1,180 @@
+package validation
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+	kvalidation "k8s.io/apimachinery/pkg/util/validation"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// ConfigFile is the default name of the configuration file, at the root of the repository
+const ConfigFile = ".argocd-checker.yaml"
+
+// OutputFormat is the format of the checker output
+type OutputFormat string
+
+const (
+	// OutputText prints the findings as human-readable logs
+	OutputText OutputFormat = "text"
+	// OutputJSON prints the findings as JSON logs, one object per line
+	OutputJSON OutputFormat = "json"
+	// OutputLogfmt prints the findings as logfmt logs
+	OutputLogfmt OutputFormat = "logfmt"
+)
+
+// Config is the content of the configuration file. The command line flags override the values of the file.
+// For example:
+//
+//	apps:
+//	- apps/*
+//	rootApps:
+//	- apps/root.yaml
+//	components:
+//	- components
+//	exclude:
+//	- components/*/experimental
+//	kubeVersion: "1.27"
+//	argocdNamespace: argocd
+//	clusters: clusters.yaml
+//	allowedRegistries:
+//	- quay.io/org
+//	- registry.k8s.io
+//	externalObjects:
+//	- kind: Secret
+//	  name: registry-*
+//	output: text
+//	rules:
+//	  ACK001:
+//	    severity: error
+//	  kustomize-build:
+//	    enabled: false
+type Config struct {
+	// Apps are the directories of the Applications, relative to the base directory (globs are supported)
+	Apps []string `json:"apps,omitempty"`
+	// RootApps are the root Applications of an app-of-apps tree, relative to the base directory (globs are supported)
+	RootApps []string `json:"rootApps,omitempty"`
+	// Components are the directories of the components, relative to the base directory (globs are supported)
+	Components []string `json:"components,omitempty"`
+	// Exclude are the patterns of the paths which are not checked, relative to the base directory
+	Exclude []string `json:"exclude,omitempty"`
+	// KubeVersion is the Kubernetes version of the target clusters (eg: `1.27`)
+	KubeVersion string `json:"kubeVersion,omitempty"`
+	// ArgoCDNamespace is the namespace of the Applications and ApplicationSets (`argocd` by default)
+	ArgoCDNamespace string `json:"argocdNamespace,omitempty"`
+	// Clusters is the path of a clusters file, or of a file or directory of Argo CD cluster Secrets,
+	// relative to the base directory
+	Clusters string `json:"clusters,omitempty"`
+	// AllowedRegistries are the registries of the container images, which may include a path (eg: `quay.io/org`)
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+	// ExternalObjects are the objects referenced by the rendered objects, which are not rendered along with them
+	ExternalObjects []ObjectReference `json:"externalObjects,omitempty"`
+	// Output is the output format: `text` (default), `json` or `logfmt`
+	Output OutputFormat `json:"output,omitempty"`
+	// Rules are the settings of the rules, indexed by rule ID or name
+	Rules map[string]RuleConfig `json:"rules,omitempty"`
+	// Bases are the discovered kustomizations which are referenced by other kustomizations, relative to the base
+	// directory. They are never read from the configuration file.
+	Bases []string `json:"-"`
+}
+
+// RuleConfig holds the settings of a rule
+type RuleConfig struct {
+	// Enabled enables or disables the rule (the rule's default applies if nil)
+	Enabled *bool `json:"enabled,omitempty"`
+	// Severity overrides the default severity of the rule
+	Severity Severity `json:"severity,omitempty"`
+}
+
+// LoadConfig reads the configuration file at the given path
+func LoadConfig(afs afero.Afero, path string) (Config, error) {
+	cfg := Config{}
+	data, err := afs.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := k8syaml.UnmarshalStrict(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+var kubeVersionRegexp = regexp.MustCompile(`^v?[0-9]+\.[0-9]+(\.[0-9]+)?$`)
+
+// a registry host, with an optional port and path (eg: `quay.io/org` or `localhost:5000`)
+var registryRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9.\-]*[a-z0-9])?(:[0-9]+)?(/[a-z0-9]([a-z0-9._\-]*[a-z0-9])?)*/?$`)
+
+// Validate verifies the values of the configuration. The rules are verified when they are configured in the registry.
+func (c Config) Validate() error {
+	for _, p := range append(append(append(append([]string{}, c.Apps...), c.RootApps...), c.Components...), c.Exclude...) {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+	}
+	if c.ArgoCDNamespace != "" {
+		if errs := kvalidation.IsDNS1123Label(c.ArgoCDNamespace); len(errs) > 0 {
+			return fmt.Errorf("invalid Argo CD namespace '%s': %s", c.ArgoCDNamespace, strings.Join(errs, ", "))
+		}
+	}
+	if c.KubeVersion != "" && !kubeVersionRegexp.MatchString(c.KubeVersion) {
+		return fmt.Errorf("invalid Kubernetes version '%s'", c.KubeVersion)
+	}
+	for _, reg := range c.AllowedRegistries {
+		if !registryRegexp.MatchString(reg) {
+			return fmt.Errorf("invalid registry '%s'", reg)
+		}
+	}
+	for _, o := range c.ExternalObjects {
+		if o.Kind == "" || o.Name == "" {
+			return fmt.Errorf("invalid external object '%s/%s': missing kind or name", o.Kind, o.Name)
+		}
+		if _, err := filepath.Match(o.Name, ""); err != nil {
+			return fmt.Errorf("invalid external object name '%s': %w", o.Name, err)
+		}
+	}
+	switch c.Output {
+	case "", OutputText, OutputJSON, OutputLogfmt:
+	default:
+		return fmt.Errorf("invalid output format '%s'", c.Output)
+	}
+	for id, rc := range c.Rules {
+		switch rc.Severity {
+		case "", SeverityWarning, SeverityError:
+		default:
+			return fmt.Errorf("invalid severity '%s' for rule '%s'", rc.Severity, id)
+		}
+	}
+	return nil
+}
+
+// ExpandPaths returns the directories matching the given patterns, relative to the base directory.
+// Paths without any glob character are returned as-is.
+func ExpandPaths(afs afero.Afero, baseDir string, patterns ...string) ([]string, error) {
+	paths := []string{}
+	for _, p := range patterns {
+		if !strings.ContainsAny(p, "*?[") {
+			paths = append(paths, p)
+			continue
+		}
+		matches, err := afero.Glob(afs, filepath.Join(baseDir, p))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", p, err)
+		}
+		for _, m := range matches {
+			if isDir, err := afs.IsDir(m); err != nil {
+				return nil, err
+			} else if !isDir {
+				continue
+			}
+			rel, err := filepath.Rel(baseDir, m)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, rel)
+		}
+	}
+	return paths, nil
+}
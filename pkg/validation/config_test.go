@@ -0,0 +1,176 @@
+package validation_test
+
+import (
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/.argocd-checker.yaml", `apps:
+- apps/*
+components:
+- components
+exclude:
+- components/*/experimental
+kubeVersion: "1.27"
+output: json
+rules:
+  ACK001:
+    severity: error
+  kustomize-build:
+    enabled: false`)
+		require.NoError(t, err)
+
+		// when
+		cfg, err := validation.LoadConfig(afs, "/path/to/.argocd-checker.yaml")
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, []string{"apps/*"}, cfg.Apps)
+		assert.Equal(t, []string{"components"}, cfg.Components)
+		assert.Equal(t, []string{"components/*/experimental"}, cfg.Exclude)
+		assert.Equal(t, "1.27", cfg.KubeVersion)
+		assert.Equal(t, validation.OutputJSON, cfg.Output)
+		require.Len(t, cfg.Rules, 2)
+		assert.Equal(t, validation.SeverityError, cfg.Rules["ACK001"].Severity)
+		require.NotNil(t, cfg.Rules["kustomize-build"].Enabled)
+		assert.False(t, *cfg.Rules["kustomize-build"].Enabled)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		// given
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/.argocd-checker.yaml", `rules:
+  ACK001:
+    level: error`)
+		require.NoError(t, err)
+
+		// when
+		_, err = validation.LoadConfig(afs, "/path/to/.argocd-checker.yaml")
+
+		// then
+		require.ErrorContains(t, err, "invalid configuration file /path/to/.argocd-checker.yaml")
+	})
+}
+
+func TestValidateConfig(t *testing.T) {
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		// given
+		cfg := validation.Config{
+			Apps: []string{"apps/["},
+		}
+
+		// when
+		err := cfg.Validate()
+
+		// then
+		require.EqualError(t, err, "invalid pattern 'apps/[': syntax error in pattern")
+	})
+
+	t.Run("invalid kube version", func(t *testing.T) {
+		// given
+		cfg := validation.Config{
+			KubeVersion: "latest",
+		}
+
+		// when
+		err := cfg.Validate()
+
+		// then
+		require.EqualError(t, err, "invalid Kubernetes version 'latest'")
+	})
+
+	t.Run("invalid allowed registry", func(t *testing.T) {
+		// given
+		cfg := validation.Config{
+			AllowedRegistries: []string{"quay.io/org", "localhost:5000", "https://quay.io"},
+		}
+
+		// when
+		err := cfg.Validate()
+
+		// then
+		require.EqualError(t, err, "invalid registry 'https://quay.io'")
+	})
+
+	t.Run("invalid external object", func(t *testing.T) {
+		// given
+		cfg := validation.Config{
+			ExternalObjects: []validation.ObjectReference{
+				{Kind: "Secret", Name: "registry-*"},
+				{Kind: "ServiceAccount"},
+			},
+		}
+
+		// when
+		err := cfg.Validate()
+
+		// then
+		require.EqualError(t, err, "invalid external object 'ServiceAccount/': missing kind or name")
+	})
+
+	t.Run("invalid output format", func(t *testing.T) {
+		// given
+		cfg := validation.Config{
+			Output: "yaml",
+		}
+
+		// when
+		err := cfg.Validate()
+
+		// then
+		require.EqualError(t, err, "invalid output format 'yaml'")
+	})
+
+	t.Run("invalid severity", func(t *testing.T) {
+		// given
+		cfg := validation.Config{
+			Rules: map[string]validation.RuleConfig{
+				"ACK001": {
+					Severity: "fatal",
+				},
+			},
+		}
+
+		// when
+		err := cfg.Validate()
+
+		// then
+		require.EqualError(t, err, "invalid severity 'fatal' for rule 'ACK001'")
+	})
+}
+
+func TestExpandPaths(t *testing.T) {
+	// given
+	afs := afero.Afero{
+		Fs: afero.NewMemMapFs(),
+	}
+	for _, dir := range []string{"/path/to/apps/dev", "/path/to/apps/prod", "/path/to/components"} {
+		err := afs.MkdirAll(dir, 0755)
+		require.NoError(t, err)
+	}
+	err := addFile(afs, "/path/to/apps/README.md", "apps")
+	require.NoError(t, err)
+
+	// when
+	paths, err := validation.ExpandPaths(afs, "/path/to", "apps/*", "components", "missing")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"apps/dev", "apps/prod", "components", "missing"}, paths)
+}
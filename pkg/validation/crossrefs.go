@@ -0,0 +1,341 @@
+package validation
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ObjectReference identifies an object which is referenced by the rendered objects, but which is not rendered
+// along with them (eg: a Secret created by an operator, or a ServiceAccount of another Application)
+type ObjectReference struct {
+	// Kind of the object (eg: `Secret`)
+	Kind string `json:"kind"`
+	// Namespace of the object. The object is in all namespaces if empty.
+	Namespace string `json:"namespace,omitempty"`
+	// Name of the object, or a pattern of names (eg: `registry-*`)
+	Name string `json:"name"`
+}
+
+// returns true if the given object matches the reference
+func (o ObjectReference) matches(kind, namespace, name string) bool {
+	if o.Kind != kind || (o.Namespace != "" && namespace != "" && o.Namespace != namespace) {
+		return false
+	}
+	matched, _ := filepath.Match(o.Name, name)
+	return matched
+}
+
+// objects which exist in all the namespaces, without being rendered
+var implicitObjects = []ObjectReference{
+	{Kind: "ServiceAccount", Name: "default"},
+	{Kind: "ConfigMap", Name: "kube-root-ca.crt"},
+}
+
+// localReference is a reference to an object in the same namespace (eg: `envFrom.configMapRef`)
+type localReference struct {
+	Name       string `json:"name"`
+	SecretName string `json:"secretName"`
+	Optional   bool   `json:"optional"`
+}
+
+// the references of a container to ConfigMaps and Secrets
+type containerReferences struct {
+	Name    string `json:"name"`
+	EnvFrom []struct {
+		ConfigMapRef *localReference `json:"configMapRef"`
+		SecretRef    *localReference `json:"secretRef"`
+	} `json:"envFrom"`
+	Env []struct {
+		ValueFrom *struct {
+			ConfigMapKeyRef *localReference `json:"configMapKeyRef"`
+			SecretKeyRef    *localReference `json:"secretKeyRef"`
+		} `json:"valueFrom"`
+	} `json:"env"`
+}
+
+// the references of a pod spec to ServiceAccounts, ConfigMaps and Secrets
+type podSpecReferences struct {
+	ServiceAccountName string `json:"serviceAccountName"`
+	ServiceAccount     string `json:"serviceAccount"`
+	Volumes            []struct {
+		ConfigMap *localReference `json:"configMap"`
+		Secret    *localReference `json:"secret"`
+		Projected *struct {
+			Sources []struct {
+				ConfigMap *localReference `json:"configMap"`
+				Secret    *localReference `json:"secret"`
+			} `json:"sources"`
+		} `json:"projected"`
+	} `json:"volumes"`
+	InitContainers []containerReferences `json:"initContainers"`
+	Containers     []containerReferences `json:"containers"`
+}
+
+// the references of a RoleBinding or ClusterRoleBinding to ServiceAccounts
+type bindingReferences struct {
+	Subjects []struct {
+		Kind      string `json:"kind"`
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+	} `json:"subjects"`
+}
+
+// the references of an Ingress to Services
+type ingressBackend struct {
+	Service *struct {
+		Name string `json:"name"`
+	} `json:"service"`
+}
+
+type ingressReferences struct {
+	Spec struct {
+		DefaultBackend *ingressBackend `json:"defaultBackend"`
+		Rules          []struct {
+			HTTP *struct {
+				Paths []struct {
+					Backend ingressBackend `json:"backend"`
+				} `json:"paths"`
+			} `json:"http"`
+		} `json:"rules"`
+	} `json:"spec"`
+}
+
+// objectRef is a reference from a rendered object to another object
+type objectRef struct {
+	// field of the referencing object (eg: `envFrom`)
+	field     string
+	kind      string
+	namespace string
+	name      string
+}
+
+// returns the references of the given rendered object to other objects
+func objectRefs(obj *yaml.RNode) []objectRef {
+	refs := []objectRef{}
+	namespace := obj.GetNamespace()
+	add := func(field, kind string, ref *localReference) {
+		if ref == nil || ref.Optional {
+			return
+		}
+		name := ref.Name
+		if name == "" {
+			name = ref.SecretName
+		}
+		if name != "" {
+			refs = append(refs, objectRef{field: field, kind: kind, namespace: namespace, name: name})
+		}
+	}
+	if spec, found := podSpec(obj); found {
+		p := podSpecReferences{}
+		if err := decode(spec, &p); err != nil {
+			return refs
+		}
+		if p.ServiceAccountName != "" {
+			refs = append(refs, objectRef{field: "serviceAccountName", kind: "ServiceAccount", namespace: namespace, name: p.ServiceAccountName})
+		} else if p.ServiceAccount != "" {
+			refs = append(refs, objectRef{field: "serviceAccount", kind: "ServiceAccount", namespace: namespace, name: p.ServiceAccount})
+		}
+		for _, v := range p.Volumes {
+			add("volumes", "ConfigMap", v.ConfigMap)
+			add("volumes", "Secret", v.Secret)
+			if v.Projected != nil {
+				for _, s := range v.Projected.Sources {
+					add("volumes", "ConfigMap", s.ConfigMap)
+					add("volumes", "Secret", s.Secret)
+				}
+			}
+		}
+		for _, c := range append(p.InitContainers, p.Containers...) {
+			for _, e := range c.EnvFrom {
+				add("envFrom", "ConfigMap", e.ConfigMapRef)
+				add("envFrom", "Secret", e.SecretRef)
+			}
+			for _, e := range c.Env {
+				if e.ValueFrom != nil {
+					add("env", "ConfigMap", e.ValueFrom.ConfigMapKeyRef)
+					add("env", "Secret", e.ValueFrom.SecretKeyRef)
+				}
+			}
+		}
+		return refs
+	}
+	switch obj.GetKind() {
+	case "RoleBinding", "ClusterRoleBinding":
+		b := bindingReferences{}
+		if err := decode(obj, &b); err != nil {
+			return refs
+		}
+		for _, s := range b.Subjects {
+			if s.Kind != "ServiceAccount" {
+				continue
+			}
+			ns := s.Namespace
+			if ns == "" {
+				ns = namespace
+			}
+			refs = append(refs, objectRef{field: "subjects", kind: "ServiceAccount", namespace: ns, name: s.Name})
+		}
+	case "Ingress":
+		i := ingressReferences{}
+		if err := decode(obj, &i); err != nil {
+			return refs
+		}
+		backends := []ingressBackend{}
+		if i.Spec.DefaultBackend != nil {
+			backends = append(backends, *i.Spec.DefaultBackend)
+		}
+		for _, r := range i.Spec.Rules {
+			if r.HTTP == nil {
+				continue
+			}
+			for _, p := range r.HTTP.Paths {
+				backends = append(backends, p.Backend)
+			}
+		}
+		for _, b := range backends {
+			if b.Service != nil && b.Service.Name != "" {
+				refs = append(refs, objectRef{field: "backend", kind: "Service", namespace: namespace, name: b.Service.Name})
+			}
+		}
+	}
+	return refs
+}
+
+// returns the labels of the pod template of the given workload (or the labels of the Pod itself), if any
+func podLabels(obj *yaml.RNode) (map[string]string, bool) {
+	path, found := podSpecPaths[obj.GetKind()]
+	if !found {
+		return nil, false
+	}
+	if len(path) == 1 {
+		return obj.GetLabels(), true
+	}
+	metadata, err := obj.Pipe(yaml.Lookup(append(append([]string{}, path[:len(path)-1]...), "metadata")...))
+	if err != nil || metadata == nil {
+		return map[string]string{}, true
+	}
+	return fieldValues(metadata, "labels"), true
+}
+
+// returns true if the given namespaces are the same. An empty namespace matches all the namespaces, since it is set
+// by Argo CD from the destination of the Application.
+func sameNamespace(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+// verifies that the Services select at least one pod template, and that the references to ConfigMaps, Secrets,
+// ServiceAccounts and Services resolve to rendered objects, or to the implicit and external objects
+func checkCrossReferences(r *reporter, opts Options, path string, objs []*yaml.RNode) {
+	byKind := map[string][]*yaml.RNode{}
+	for _, obj := range objs {
+		byKind[obj.GetKind()] = append(byKind[obj.GetKind()], obj)
+	}
+	for _, obj := range objs {
+		if obj.GetKind() == "Service" && obj.GetApiVersion() == "v1" {
+			checkServiceSelector(r, opts, path, obj, objs)
+		}
+		for _, ref := range objectRefs(obj) {
+			if resolved(opts, byKind[ref.kind], ref) {
+				continue
+			}
+			f := Finding{
+				Path:    path,
+				Message: fmt.Sprintf("reference to a missing %s", ref.kind),
+				KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "field", ref.field, "target", ref.name},
+			}
+			if ref.namespace != obj.GetNamespace() {
+				// eg: the subjects of RoleBindings
+				f.KeyVals = append(f.KeyVals, "namespace", ref.namespace)
+			}
+			if s, found := similarName(ref.name, byKind[ref.kind]); found {
+				f.Suggestion = fmt.Sprintf("name: %s", s)
+			}
+			r.report(DanglingReferenceRule, f)
+		}
+	}
+}
+
+// returns true if the given reference resolves to one of the given objects, or to an implicit or external object
+func resolved(opts Options, objs []*yaml.RNode, ref objectRef) bool {
+	for _, obj := range objs {
+		if obj.GetName() == ref.name && sameNamespace(obj.GetNamespace(), ref.namespace) {
+			return true
+		}
+	}
+	for _, o := range append(append([]ObjectReference{}, implicitObjects...), opts.ExternalObjects...) {
+		if o.matches(ref.kind, ref.namespace, ref.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the name of the object which was probably meant by the given name: with a name prefix or suffix
+// (eg: added by kustomize), or a close name
+func similarName(name string, objs []*yaml.RNode) (string, bool) {
+	candidates := []string{}
+	for _, obj := range objs {
+		candidates = append(candidates, obj.GetName())
+	}
+	sort.Strings(candidates)
+	for _, c := range candidates {
+		if c == name {
+			// in another namespace
+			return "", false
+		}
+	}
+	for _, c := range candidates {
+		if strings.HasSuffix(c, "-"+name) || strings.HasPrefix(c, name+"-") {
+			return c, true
+		}
+	}
+	return closest(name, candidates)
+}
+
+// reports the Service whose selector doesn't match the labels of any pod template in the same namespace, unless
+// the Service is declared as an external object (ie: its pods are not rendered along with it)
+func checkServiceSelector(r *reporter, opts Options, path string, svc *yaml.RNode, objs []*yaml.RNode) {
+	for _, o := range opts.ExternalObjects {
+		if o.matches(svc.GetKind(), svc.GetNamespace(), svc.GetName()) {
+			return
+		}
+	}
+	spec, err := svc.Pipe(yaml.Lookup("spec"))
+	if err != nil || spec == nil {
+		return
+	}
+	selected := fieldValues(spec, "selector")
+	if len(selected) == 0 {
+		// eg: Services with manually managed Endpoints, or ExternalName Services
+		return
+	}
+	for _, obj := range objs {
+		labels, found := podLabels(obj)
+		if !found || !sameNamespace(obj.GetNamespace(), svc.GetNamespace()) {
+			continue
+		}
+		matches := true
+		for k, v := range selected {
+			if labels[k] != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return
+		}
+	}
+	selectors := []string{}
+	for _, k := range sortedKeys(selected) {
+		selectors = append(selectors, k+"="+selected[k])
+	}
+	r.report(UnmatchedSelectorRule, Finding{
+		Path:    path,
+		Message: "Service selector doesn't match any pod template",
+		KeyVals: []interface{}{"kind", svc.GetKind(), "name", svc.GetName(), "selector", strings.Join(selectors, ",")},
+	})
+}
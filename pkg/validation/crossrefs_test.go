@@ -0,0 +1,348 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCrossReferences(t *testing.T) {
+
+	newFS := func(t *testing.T, kustomization, objects string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- objects.yaml
+`+kustomization)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/objects.yaml", objects)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `namePrefix: prod-
+namespace: cookie
+configMapGenerator:
+- name: cookie-config
+  literals:
+  - flavor=chocolate`, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cookie
+spec:
+  template:
+    metadata:
+      labels:
+        app: cookie
+        tier: backend
+    spec:
+      serviceAccountName: cookie
+      containers:
+      - name: cookie
+        image: quay.io/org/cookie:v1.2.3
+        envFrom:
+        - configMapRef:
+            name: cookie-config
+        env:
+        - name: TOKEN
+          valueFrom:
+            secretKeyRef:
+              name: registry-token
+              key: token
+        - name: OPTIONAL
+          valueFrom:
+            secretKeyRef:
+              name: optional
+              key: value
+              optional: true
+      volumes:
+      - name: ca
+        configMap:
+          name: kube-root-ca.crt
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: quay.io/org/cookie:v1.2.3
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cookie
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: cookie
+spec:
+  selector:
+    app: cookie
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: postgres
+spec:
+  selector:
+    app: postgres # pods created by an operator
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: cookie
+spec:
+  rules:
+  - http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: cookie
+            port:
+              number: 8080
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: cookie
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- kind: ServiceAccount
+  name: cookie
+- kind: ServiceAccount
+  name: argocd-application-controller
+  namespace: argocd
+- kind: Group
+  name: developers`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{
+			ExternalObjects: []validation.ObjectReference{
+				{Kind: "Secret", Name: "registry-*"},
+				{Kind: "ServiceAccount", Namespace: "argocd", Name: "argocd-application-controller"},
+				{Kind: "Service", Namespace: "cookie", Name: "*-postgres"},
+			},
+		}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("unmatched selector", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, "", `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cookie
+  namespace: cookie
+spec:
+  template:
+    metadata:
+      labels:
+        app: cookie
+    spec:
+      containers:
+      - name: cookie
+        image: quay.io/org/cookie:v1.2.3
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: cookie
+  namespace: cookie
+spec:
+  selector:
+    app: cookie
+    tier: backend
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: external
+  namespace: cookie
+spec:
+  type: ExternalName
+  externalName: cookies.example.com`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			// reported as warnings by default
+			require.NoError(t, err)
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "Service selector doesn't match any pod template",
+					KeyVals: []interface{}{
+						"rule", "ACK030",
+						"path", "/path/to/components/cookie",
+						"kind", "Service",
+						"name", "cookie",
+						"selector", "app=cookie,tier=backend",
+					},
+				},
+			}, logger.Warnings())
+		})
+
+		t.Run("dangling references", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, "", `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: cookie
+  namespace: cookie
+spec:
+  template:
+    spec:
+      serviceAccountName: cookie
+      containers:
+      - name: cookie
+        image: quay.io/org/cookie:v1.2.3
+        envFrom:
+        - secretRef:
+            name: cookie-secrets
+      volumes:
+      - name: config
+        configMap:
+          name: pasta
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: cookie-sa
+  namespace: cookie
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: cookie-secret
+  namespace: cookie
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: cookie
+  namespace: cookie
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- kind: ServiceAccount
+  name: cookie-sa
+  namespace: pasta
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: cookie
+  namespace: cookie
+spec:
+  defaultBackend:
+    service:
+      name: cookie
+      port:
+        number: 8080`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "reference to a missing ServiceAccount",
+					KeyVals: []interface{}{
+						"rule", "ACK031",
+						"path", "/path/to/components/cookie",
+						"kind", "RoleBinding",
+						"name", "cookie",
+						"field", "subjects",
+						"target", "cookie-sa",
+						"namespace", "pasta",
+					},
+				},
+				{
+					Msg: "reference to a missing ServiceAccount",
+					KeyVals: []interface{}{
+						"rule", "ACK031",
+						"path", "/path/to/components/cookie",
+						"kind", "StatefulSet",
+						"name", "cookie",
+						"field", "serviceAccountName",
+						"target", "cookie",
+						"suggestion", "name: cookie-sa",
+					},
+				},
+				{
+					Msg: "reference to a missing ConfigMap",
+					KeyVals: []interface{}{
+						"rule", "ACK031",
+						"path", "/path/to/components/cookie",
+						"kind", "StatefulSet",
+						"name", "cookie",
+						"field", "volumes",
+						"target", "pasta",
+					},
+				},
+				{
+					Msg: "reference to a missing Secret",
+					KeyVals: []interface{}{
+						"rule", "ACK031",
+						"path", "/path/to/components/cookie",
+						"kind", "StatefulSet",
+						"name", "cookie",
+						"field", "envFrom",
+						"target", "cookie-secrets",
+						"suggestion", "name: cookie-secret",
+					},
+				},
+				{
+					Msg: "reference to a missing Service",
+					KeyVals: []interface{}{
+						"rule", "ACK031",
+						"path", "/path/to/components/cookie",
+						"kind", "Ingress",
+						"name", "cookie",
+						"field", "backend",
+						"target", "cookie",
+					},
+				},
+			}, logger.Warnings())
+		})
+	})
+}
@@ -0,0 +1,230 @@
+package validation
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	argocdv1alpha1 "github.com/codeready-toolchain/argocd-checker/pkg/argocd-types/application/v1alpha1"
+
+	"github.com/spf13/afero"
+	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// deprecatedAPI is a Kubernetes API which is deprecated, and removed in a later version
+// (see https://kubernetes.io/docs/reference/using-api/deprecation-guide/)
+type deprecatedAPI struct {
+	apiVersion   string
+	kinds        []string
+	deprecatedIn string
+	removedIn    string
+	// replacement is the apiVersion which replaces the deprecated one (none if empty)
+	replacement string
+}
+
+var deprecatedAPIs = []deprecatedAPI{
+	{"extensions/v1beta1", []string{"Deployment", "DaemonSet", "ReplicaSet"}, "1.8", "1.16", "apps/v1"},
+	{"apps/v1beta1", []string{"Deployment", "StatefulSet", "ReplicaSet"}, "1.9", "1.16", "apps/v1"},
+	{"apps/v1beta2", []string{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet"}, "1.9", "1.16", "apps/v1"},
+	{"extensions/v1beta1", []string{"NetworkPolicy"}, "1.9", "1.16", "networking.k8s.io/v1"},
+	{"extensions/v1beta1", []string{"PodSecurityPolicy"}, "1.11", "1.16", "policy/v1beta1"},
+	{"extensions/v1beta1", []string{"Ingress"}, "1.14", "1.22", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", []string{"Ingress", "IngressClass"}, "1.19", "1.22", "networking.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", []string{"CustomResourceDefinition"}, "1.16", "1.22", "apiextensions.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", []string{"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"}, "1.16", "1.22", "admissionregistration.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", []string{"APIService"}, "1.19", "1.22", "apiregistration.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", []string{"CertificateSigningRequest"}, "1.19", "1.22", "certificates.k8s.io/v1"},
+	{"coordination.k8s.io/v1beta1", []string{"Lease"}, "1.19", "1.22", "coordination.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", []string{"ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding"}, "1.17", "1.22", "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", []string{"PriorityClass"}, "1.14", "1.22", "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", []string{"CSIDriver", "CSINode", "StorageClass", "VolumeAttachment"}, "1.19", "1.22", "storage.k8s.io/v1"},
+	{"batch/v1beta1", []string{"CronJob"}, "1.21", "1.25", "batch/v1"},
+	{"discovery.k8s.io/v1beta1", []string{"EndpointSlice"}, "1.21", "1.25", "discovery.k8s.io/v1"},
+	{"events.k8s.io/v1beta1", []string{"Event"}, "1.19", "1.25", "events.k8s.io/v1"},
+	{"autoscaling/v2beta1", []string{"HorizontalPodAutoscaler"}, "1.22", "1.25", "autoscaling/v2"},
+	{"policy/v1beta1", []string{"PodDisruptionBudget"}, "1.21", "1.25", "policy/v1"},
+	{"policy/v1beta1", []string{"PodSecurityPolicy"}, "1.21", "1.25", ""},
+	{"node.k8s.io/v1beta1", []string{"RuntimeClass"}, "1.20", "1.25", "node.k8s.io/v1"},
+	{"autoscaling/v2beta2", []string{"HorizontalPodAutoscaler"}, "1.23", "1.26", "autoscaling/v2"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", []string{"FlowSchema", "PriorityLevelConfiguration"}, "1.23", "1.26", "flowcontrol.apiserver.k8s.io/v1beta3"},
+	{"storage.k8s.io/v1beta1", []string{"CSIStorageCapacity"}, "1.24", "1.27", "storage.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", []string{"FlowSchema", "PriorityLevelConfiguration"}, "1.26", "1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", []string{"FlowSchema", "PriorityLevelConfiguration"}, "1.29", "1.32", "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// returns the deprecation of the given API, if any
+func lookupDeprecatedAPI(apiVersion, kind string) (deprecatedAPI, bool) {
+	for _, d := range deprecatedAPIs {
+		if d.apiVersion == apiVersion && contains(d.kinds, kind) {
+			return d, true
+		}
+	}
+	return deprecatedAPI{}, false
+}
+
+// returns the major and minor numbers of the given Kubernetes version (eg: `1.27`, `v1.27.3`)
+func parseKubeVersion(version string) (int, int, error) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("invalid Kubernetes version '%s'", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Kubernetes version '%s'", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Kubernetes version '%s'", version)
+	}
+	return major, minor, nil
+}
+
+// returns true if the given Kubernetes version is the same as, or later than, the other one
+func atLeast(version, other string) bool {
+	major, minor, err := parseKubeVersion(version)
+	if err != nil {
+		return false
+	}
+	otherMajor, otherMinor, err := parseKubeVersion(other)
+	if err != nil {
+		return false
+	}
+	return major > otherMajor || (major == otherMajor && minor >= otherMinor)
+}
+
+// reports the rendered objects whose API is deprecated or removed in the given Kubernetes version, with the
+// replacement apiVersion as a suggestion. The given keyVals are appended to the findings (eg: the Application).
+func checkDeprecatedAPIs(r *reporter, kubeVersion, path string, objs []*yaml.RNode, keyVals ...interface{}) {
+	if kubeVersion == "" {
+		return
+	}
+	for _, obj := range objs {
+		d, found := lookupDeprecatedAPI(obj.GetApiVersion(), obj.GetKind())
+		if !found || !atLeast(kubeVersion, d.deprecatedIn) {
+			continue
+		}
+		f := Finding{
+			Path:    path,
+			KeyVals: append([]interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "apiVersion", obj.GetApiVersion(), "kubeVersion", kubeVersion}, keyVals...),
+		}
+		if d.replacement != "" {
+			f.Suggestion = fmt.Sprintf("apiVersion: %s", d.replacement)
+		}
+		if atLeast(kubeVersion, d.removedIn) {
+			f.Message = "API was removed"
+			f.KeyVals = append(f.KeyVals, "removedIn", d.removedIn)
+			r.report(RemovedAPIRule, f)
+			continue
+		}
+		f.Message = "API is deprecated"
+		f.KeyVals = append(f.KeyVals, "deprecatedIn", d.deprecatedIn, "removedIn", d.removedIn)
+		r.report(DeprecatedAPIRule, f)
+	}
+}
+
+// returns the declared cluster of the given destination, if it has a Kubernetes version
+func (o Options) destinationCluster(dest argocdv1alpha1.ApplicationDestination) (Cluster, bool) {
+	for _, c := range o.Clusters {
+		if c.KubeVersion == "" {
+			continue
+		}
+		if (dest.Server != "" && strings.TrimSuffix(c.Server, "/") == strings.TrimSuffix(dest.Server, "/")) ||
+			(dest.Server == "" && dest.Name != "" && c.Name == dest.Name) {
+			return c, true
+		}
+	}
+	return Cluster{}, false
+}
+
+// returns true if some of the declared clusters have a Kubernetes version
+func (o Options) clusterKubeVersions() bool {
+	for _, c := range o.Clusters {
+		if c.KubeVersion != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// clusterSources records the source directories of the Applications, depending on whether the Kubernetes version of
+// their destination cluster is known. The kustomizations of the directories which are only deployed to clusters with
+// a known version are not verified against the `KubeVersion` of the options, since the sources of the Applications are
+// verified against the versions of their clusters.
+type clusterSources struct {
+	mu          sync.Mutex
+	versioned   map[string]bool
+	unversioned map[string]bool
+}
+
+func newClusterSources() *clusterSources {
+	return &clusterSources{
+		versioned:   map[string]bool{},
+		unversioned: map[string]bool{},
+	}
+}
+
+// records the given source directory, deployed to a cluster whose Kubernetes version is known or not
+func (s *clusterSources) add(dir string, versioned bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if versioned {
+		s.versioned[dir] = true
+	} else {
+		s.unversioned[dir] = true
+	}
+}
+
+// returns true if the given directory is only deployed to clusters whose Kubernetes version is known
+func (s *clusterSources) versionedOnly(dir string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versioned[dir] && !s.unversioned[dir]
+}
+
+// renders the sources of the given Application and reports the deprecated and removed APIs in the Kubernetes
+// version of its destination cluster, if it is known (the `KubeVersion` of the options applies otherwise, when the
+// kustomizations are checked). The sources which can't be rendered are skipped, since they are reported by the other
+// checks. The sources of the ApplicationSets are deployed to clusters whose version is unknown.
+func checkApplicationAPIs(logger Logger, r *reporter, afs afero.Afero, fsys kfsys.FileSystem, opts Options, baseDir, path string, obj *yaml.RNode, sources argocdv1alpha1.ApplicationSources) error {
+	cluster, found := Cluster{}, false
+	if obj.GetKind() == "Application" {
+		app := &argocdv1alpha1.Application{}
+		if err := decode(obj, app); err != nil {
+			return err
+		}
+		cluster, found = opts.destinationCluster(app.Spec.Destination)
+	}
+	for _, s := range sources {
+		if s.Path == "" || strings.Contains(s.Path, "{{") {
+			continue
+		}
+		dir := filepath.Join(baseDir, s.Path)
+		if isDir, err := afs.IsDir(dir); err != nil || !isDir {
+			continue
+		}
+		opts.sources.add(dir, found)
+		if !found {
+			continue
+		}
+		objs, err := renderSource(logger, afs, fsys, opts, dir)
+		if err != nil {
+			logger.Debug("skipping source which can't be rendered", "path", path, "name", obj.GetName(), "source", s.Path, "err", err)
+			continue
+		}
+		name := cluster.Name
+		if name == "" {
+			name = cluster.Server
+		}
+		checkDeprecatedAPIs(r, cluster.KubeVersion, path, objs, "application", obj.GetName(), "cluster", name)
+	}
+	return nil
+}
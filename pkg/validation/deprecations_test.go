@@ -0,0 +1,336 @@
+package validation_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDeprecatedAPIs(t *testing.T) {
+
+	newFS := func(t *testing.T) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		for path, content := range map[string]string{
+			"/path/to/components/cookie/kustomization.yaml": `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- objects.yaml`,
+			"/path/to/components/cookie/objects.yaml": `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: registry.k8s.io/kubectl:v1.28.0
+---
+apiVersion: policy/v1beta1
+kind: PodSecurityPolicy
+metadata:
+  name: restricted
+---
+apiVersion: autoscaling/v2beta2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: cookie
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: pasta`,
+		} {
+			err := addFile(afs, path, content)
+			require.NoError(t, err)
+		}
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+
+		t.Run("no kube version", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+
+		t.Run("apis not deprecated yet", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{KubeVersion: "1.20"}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("deprecated apis", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{KubeVersion: "v1.24.3"}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			require.Len(t, logger.Warnings(), 3)
+			assert.Equal(t, LogRecord{
+				Msg: "API is deprecated",
+				KeyVals: []interface{}{
+					"rule", "ACK032",
+					"path", "/path/to/components/cookie",
+					"kind", "CronJob",
+					"name", "cleanup",
+					"apiVersion", "batch/v1beta1",
+					"kubeVersion", "v1.24.3",
+					"deprecatedIn", "1.21",
+					"removedIn", "1.25",
+					"suggestion", "apiVersion: batch/v1",
+				},
+			}, logger.Warnings()[1])
+		})
+
+		t.Run("removed apis", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{KubeVersion: "1.26"}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 3 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "API was removed",
+					KeyVals: []interface{}{
+						"rule", "ACK033",
+						"path", "/path/to/components/cookie",
+						"kind", "PodSecurityPolicy",
+						"name", "restricted",
+						"apiVersion", "policy/v1beta1",
+						"kubeVersion", "1.26",
+						"removedIn", "1.25",
+					},
+				},
+				{
+					Msg: "API was removed",
+					KeyVals: []interface{}{
+						"rule", "ACK033",
+						"path", "/path/to/components/cookie",
+						"kind", "CronJob",
+						"name", "cleanup",
+						"apiVersion", "batch/v1beta1",
+						"kubeVersion", "1.26",
+						"removedIn", "1.25",
+						"suggestion", "apiVersion: batch/v1",
+					},
+				},
+				{
+					Msg: "API was removed",
+					KeyVals: []interface{}{
+						"rule", "ACK033",
+						"path", "/path/to/components/cookie",
+						"kind", "HorizontalPodAutoscaler",
+						"name", "cookie",
+						"apiVersion", "autoscaling/v2beta2",
+						"kubeVersion", "1.26",
+						"removedIn", "1.26",
+						"suggestion", "apiVersion: autoscaling/v2",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("removed apis in destination cluster", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+			err := addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    name: prod
+  source:
+    path: components/cookie
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie-staging
+spec:
+  destination:
+    server: https://staging.example.com:6443
+  source:
+    path: components/cookie`)
+			require.NoError(t, err)
+			opts := validation.Options{
+				KubeVersion: "1.20",
+				Clusters: []validation.Cluster{
+					{Name: "prod", Server: "https://prod.example.com:6443", KubeVersion: "1.25"},
+					{Name: "staging", Server: "https://staging.example.com:6443"},
+				},
+			}
+
+			// when
+			err = validation.CheckApplications(logger, afs, opts, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 2 error(s)")
+			require.Len(t, logger.Errors(), 2)
+			assert.Equal(t, LogRecord{
+				Msg: "API was removed",
+				KeyVals: []interface{}{
+					"rule", "ACK033",
+					"path", "/path/to/apps/cookie.yaml",
+					"kind", "PodSecurityPolicy",
+					"name", "restricted",
+					"apiVersion", "policy/v1beta1",
+					"kubeVersion", "1.25",
+					"application", "cookie",
+					"cluster", "prod",
+					"removedIn", "1.25",
+				},
+			}, logger.Errors()[0])
+			assert.Equal(t, "CronJob", logger.Errors()[1].KeyVals[5])
+			require.Len(t, logger.Warnings(), 1)
+			assert.Equal(t, "API is deprecated", logger.Warnings()[0].Msg)
+			assert.Equal(t, "HorizontalPodAutoscaler", logger.Warnings()[0].KeyVals[5])
+		})
+	})
+	t.Run("checker", func(t *testing.T) {
+
+		newChecker := func(t *testing.T, apps string) *validation.Checker {
+			afs := newFS(t)
+			err := addFile(afs, "/path/to/apps/cookie.yaml", apps)
+			require.NoError(t, err)
+			return validation.NewChecker(
+				validation.WithFs(afs.Fs),
+				validation.WithBaseDir("/path/to"),
+				validation.WithApps("apps"),
+				validation.WithComponents("components"),
+				validation.WithOptions(validation.Options{
+					Clusters: []validation.Cluster{
+						{Name: "prod", Server: "https://prod.example.com:6443", KubeVersion: "1.25"},
+						{Name: "staging", Server: "https://staging.example.com:6443"},
+					},
+				}),
+				validation.WithKubeVersion("1.22"),
+			)
+		}
+
+		t.Run("only the version of the destination cluster", func(t *testing.T) {
+			// given
+			checker := newChecker(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    name: prod
+  source:
+    path: components/cookie`)
+
+			// when
+			report, err := checker.Run(context.Background())
+
+			// then
+			require.NoError(t, err)
+			// no findings for the `--kube-version` about the same objects
+			require.Len(t, report.Findings, 3)
+			for _, f := range report.Findings {
+				assert.Equal(t, "/path/to/apps/cookie.yaml", f.Path)
+				assert.Contains(t, f.KeyVals, "prod")
+				assert.Contains(t, f.KeyVals, "1.25")
+			}
+			require.Len(t, report.Errors(), 2)
+			assert.Equal(t, "PodSecurityPolicy", report.Errors()[0].KeyVals[1])
+			assert.Equal(t, "CronJob", report.Errors()[1].KeyVals[1])
+			require.Len(t, report.Warnings(), 1)
+			assert.Equal(t, "HorizontalPodAutoscaler", report.Warnings()[0].KeyVals[1])
+		})
+
+		t.Run("fallback for the cluster without version", func(t *testing.T) {
+			// given
+			checker := newChecker(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    name: prod
+  source:
+    path: components/cookie
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie-staging
+spec:
+  destination:
+    name: staging
+  source:
+    path: components/cookie`)
+
+			// when
+			report, err := checker.Run(context.Background())
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, report.Findings, 5)
+			// the kustomization is also deployed to a cluster whose version is unknown
+			fallback := []validation.Finding{}
+			for _, f := range report.Findings {
+				if f.Path == "/path/to/components/cookie" {
+					fallback = append(fallback, f)
+				}
+			}
+			require.Len(t, fallback, 2)
+			for _, f := range fallback {
+				assert.Equal(t, validation.SeverityWarning, f.Severity)
+				assert.Contains(t, f.KeyVals, "1.22")
+			}
+		})
+	})
+}
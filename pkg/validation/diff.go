@@ -0,0 +1,300 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DiffFormat is the output format of the diffs
+type DiffFormat string
+
+const (
+	// DiffFormatText is the plain text format, for terminals
+	DiffFormatText DiffFormat = "text"
+	// DiffFormatMarkdown is the markdown format, for pull request comments
+	DiffFormatMarkdown DiffFormat = "markdown"
+)
+
+// ChangeType is the type of change of an object or a field
+type ChangeType string
+
+const (
+	// ChangeAdded is an object or a field which only exists in the new revision
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved is an object or a field which only exists in the old revision
+	ChangeRemoved ChangeType = "removed"
+	// ChangeModified is an object or a field which exists in both revisions, with different values
+	ChangeModified ChangeType = "modified"
+)
+
+// ApplicationDiff holds the changes of the objects rendered for an Application between two revisions
+type ApplicationDiff struct {
+	// Name of the Application
+	Name string
+	// Objects which were added, removed or modified
+	Objects []ObjectDiff
+}
+
+// ObjectDiff holds the changes of a rendered object between two revisions
+type ObjectDiff struct {
+	Type      ChangeType
+	Kind      string
+	Namespace string
+	Name      string
+	// Fields which were added, removed or modified (only for modified objects)
+	Fields []FieldDiff
+}
+
+// FieldDiff holds the change of a field of an object, along with its old and/or new value
+type FieldDiff struct {
+	Type ChangeType
+	// Path of the field (eg: `spec.template.spec.containers[0].image`)
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// DiffApplications compares the objects rendered for the Applications of two revisions, regardless of the order of
+// the objects and of their keys. Only the Applications with changes are returned.
+func DiffApplications(oldApps, newApps []RenderedApplication) ([]ApplicationDiff, error) {
+	oldObjs, err := indexApplications(oldApps)
+	if err != nil {
+		return nil, err
+	}
+	newObjs, err := indexApplications(newApps)
+	if err != nil {
+		return nil, err
+	}
+	diffs := []ApplicationDiff{}
+	for _, name := range sortedKeys(oldObjs, newObjs) {
+		d := ApplicationDiff{
+			Name:    name,
+			Objects: diffObjects(oldObjs[name], newObjs[name]),
+		}
+		if len(d.Objects) > 0 {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs, nil
+}
+
+type renderedObject struct {
+	kind      string
+	namespace string
+	name      string
+	content   map[string]interface{}
+}
+
+// returns the rendered objects, indexed by Application name and by object key
+func indexApplications(apps []RenderedApplication) (map[string]map[string]renderedObject, error) {
+	index := map[string]map[string]renderedObject{}
+	for _, app := range apps {
+		if _, found := index[app.Name]; !found {
+			index[app.Name] = map[string]renderedObject{}
+		}
+		for _, obj := range app.Objects {
+			content, err := obj.Map()
+			if err != nil {
+				return nil, err
+			}
+			key := strings.Join([]string{obj.GetApiVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName()}, "/")
+			index[app.Name][key] = renderedObject{
+				kind:      obj.GetKind(),
+				namespace: obj.GetNamespace(),
+				name:      obj.GetName(),
+				content:   content,
+			}
+		}
+	}
+	return index, nil
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func diffObjects(oldObjs, newObjs map[string]renderedObject) []ObjectDiff {
+	diffs := []ObjectDiff{}
+	for _, key := range sortedKeys(oldObjs, newObjs) {
+		o, oldFound := oldObjs[key]
+		n, newFound := newObjs[key]
+		switch {
+		case !oldFound:
+			diffs = append(diffs, ObjectDiff{Type: ChangeAdded, Kind: n.kind, Namespace: n.namespace, Name: n.name})
+		case !newFound:
+			diffs = append(diffs, ObjectDiff{Type: ChangeRemoved, Kind: o.kind, Namespace: o.namespace, Name: o.name})
+		default:
+			if isSecret(o.content) && isSecret(n.content) {
+				maskSecretValues(o.content, n.content)
+			}
+			if fields := diffFields("", o.content, n.content); len(fields) > 0 {
+				diffs = append(diffs, ObjectDiff{Type: ChangeModified, Kind: n.kind, Namespace: n.namespace, Name: n.name, Fields: fields})
+			}
+		}
+	}
+	return diffs
+}
+
+func isSecret(content map[string]interface{}) bool {
+	return content["apiVersion"] == "v1" && content["kind"] == "Secret"
+}
+
+// replaces the values of the `data` and `stringData` of the given Secrets with masks, the way Argo CD does, so that
+// the diffs only show which keys were added, removed or modified. Equal values get the same mask.
+func maskSecretValues(objs ...map[string]interface{}) {
+	masks := map[string]string{}
+	for _, obj := range objs {
+		for _, field := range []string{"data", "stringData"} {
+			values, ok := obj[field].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, k := range sortedKeys(values) {
+				v := fmt.Sprintf("%v", values[k])
+				if _, found := masks[v]; !found {
+					masks[v] = strings.Repeat("+", 8+len(masks))
+				}
+				values[k] = masks[v]
+			}
+		}
+	}
+}
+
+// returns the changes between the old and new values at the given path
+func diffFields(path string, oldValue, newValue interface{}) []FieldDiff {
+	switch o := oldValue.(type) {
+	case map[string]interface{}:
+		if n, ok := newValue.(map[string]interface{}); ok {
+			diffs := []FieldDiff{}
+			for _, k := range sortedKeys(o, n) {
+				p := fieldPath(path, k)
+				ov, oldFound := o[k]
+				nv, newFound := n[k]
+				switch {
+				case !oldFound:
+					diffs = append(diffs, FieldDiff{Type: ChangeAdded, Path: p, New: nv})
+				case !newFound:
+					diffs = append(diffs, FieldDiff{Type: ChangeRemoved, Path: p, Old: ov})
+				default:
+					diffs = append(diffs, diffFields(p, ov, nv)...)
+				}
+			}
+			return diffs
+		}
+	case []interface{}:
+		if n, ok := newValue.([]interface{}); ok {
+			diffs := []FieldDiff{}
+			for i := 0; i < len(o) || i < len(n); i++ {
+				p := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(o):
+					diffs = append(diffs, FieldDiff{Type: ChangeAdded, Path: p, New: n[i]})
+				case i >= len(n):
+					diffs = append(diffs, FieldDiff{Type: ChangeRemoved, Path: p, Old: o[i]})
+				default:
+					diffs = append(diffs, diffFields(p, o[i], n[i])...)
+				}
+			}
+			return diffs
+		}
+	}
+	if reflect.DeepEqual(oldValue, newValue) {
+		return nil
+	}
+	return []FieldDiff{{Type: ChangeModified, Path: path, Old: oldValue, New: newValue}}
+}
+
+// appends the given key to the path, using the `["key"]` notation if the key contains dots
+// (eg: `metadata.annotations["argocd.argoproj.io/sync-wave"]`)
+func fieldPath(path, key string) string {
+	if strings.Contains(key, ".") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// WriteDiff writes the given diffs in the given format
+func WriteDiff(w io.Writer, diffs []ApplicationDiff, format DiffFormat) error {
+	if format != DiffFormatText && format != DiffFormatMarkdown {
+		return fmt.Errorf("invalid diff format: '%s'", format)
+	}
+	out := &strings.Builder{}
+	if len(diffs) == 0 {
+		fmt.Fprintln(out, "no changes")
+	}
+	for _, app := range diffs {
+		if format == DiffFormatMarkdown {
+			fmt.Fprintf(out, "### Application `%s`\n\n```diff\n", app.Name)
+		} else {
+			fmt.Fprintf(out, "Application %s\n", app.Name)
+		}
+		for _, obj := range app.Objects {
+			name := obj.Name
+			if obj.Namespace != "" {
+				name = obj.Namespace + "/" + name
+			}
+			fmt.Fprintf(out, "%s %s %s\n", changeSymbol(obj.Type, format), obj.Kind, name)
+			for _, f := range obj.Fields {
+				switch {
+				case f.Type == ChangeAdded:
+					fmt.Fprintf(out, "+   %s: %s\n", f.Path, formatValue(f.New))
+				case f.Type == ChangeRemoved:
+					fmt.Fprintf(out, "-   %s: %s\n", f.Path, formatValue(f.Old))
+				case format == DiffFormatMarkdown:
+					fmt.Fprintf(out, "-   %s: %s\n", f.Path, formatValue(f.Old))
+					fmt.Fprintf(out, "+   %s: %s\n", f.Path, formatValue(f.New))
+				default:
+					fmt.Fprintf(out, "~   %s: %s -> %s\n", f.Path, formatValue(f.Old), formatValue(f.New))
+				}
+			}
+		}
+		if format == DiffFormatMarkdown {
+			fmt.Fprint(out, "```\n")
+		}
+		fmt.Fprintln(out)
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+func changeSymbol(t ChangeType, format DiffFormat) string {
+	switch t {
+	case ChangeAdded:
+		return "+"
+	case ChangeRemoved:
+		return "-"
+	default:
+		if format == DiffFormatMarkdown {
+			// highlighted as a comment in the `diff` code blocks
+			return "#"
+		}
+		return "~"
+	}
+}
+
+// returns the given value as compact JSON, which also fits in a single line for maps and lists
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
@@ -0,0 +1,228 @@
+package validation_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestDiffApplications(t *testing.T) {
+
+	newApp := func(t *testing.T, name string, objs ...string) validation.RenderedApplication {
+		app := validation.RenderedApplication{
+			Name: name,
+		}
+		for _, o := range objs {
+			obj, err := yaml.Parse(o)
+			require.NoError(t, err)
+			app.Objects = append(app.Objects, obj)
+		}
+		return app
+	}
+
+	oldApps := []validation.RenderedApplication{
+		newApp(t, "cookie", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+  namespace: cookie
+  annotations:
+    argocd.argoproj.io/sync-wave: "1"
+data:
+  cookie: yummy
+  chocolate: dark`,
+			`apiVersion: v1
+kind: Namespace
+metadata:
+  name: cookie`),
+		newApp(t, "pasta", `apiVersion: v1
+kind: Namespace
+metadata:
+  name: pasta`),
+	}
+	newApps := []validation.RenderedApplication{
+		newApp(t, "cookie", `apiVersion: v1
+kind: Secret
+metadata:
+  name: cookie
+  namespace: cookie`,
+			// same object, with a different key order
+			`kind: ConfigMap
+apiVersion: v1
+data:
+  cookie: yucky
+  sugar: brown
+metadata:
+  annotations:
+    argocd.argoproj.io/sync-wave: "1"
+  namespace: cookie
+  name: cookie`),
+		newApp(t, "pasta", `metadata:
+  name: pasta
+kind: Namespace
+apiVersion: v1`),
+	}
+
+	t.Run("diff", func(t *testing.T) {
+		// when
+		diffs, err := validation.DiffApplications(oldApps, newApps)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []validation.ApplicationDiff{
+			{
+				Name: "cookie",
+				Objects: []validation.ObjectDiff{
+					{
+						Type:      validation.ChangeModified,
+						Kind:      "ConfigMap",
+						Namespace: "cookie",
+						Name:      "cookie",
+						Fields: []validation.FieldDiff{
+							{Type: validation.ChangeRemoved, Path: "data.chocolate", Old: "dark"},
+							{Type: validation.ChangeModified, Path: "data.cookie", Old: "yummy", New: "yucky"},
+							{Type: validation.ChangeAdded, Path: "data.sugar", New: "brown"},
+						},
+					},
+					{
+						Type:      validation.ChangeRemoved,
+						Kind:      "Namespace",
+						Namespace: "",
+						Name:      "cookie",
+					},
+					{
+						Type:      validation.ChangeAdded,
+						Kind:      "Secret",
+						Namespace: "cookie",
+						Name:      "cookie",
+					},
+				},
+			},
+		}, diffs)
+	})
+
+	t.Run("write", func(t *testing.T) {
+		// given
+		diffs, err := validation.DiffApplications(oldApps, newApps)
+		require.NoError(t, err)
+
+		t.Run("text", func(t *testing.T) {
+			// given
+			out := &strings.Builder{}
+
+			// when
+			err := validation.WriteDiff(out, diffs, validation.DiffFormatText)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, `Application cookie
+~ ConfigMap cookie/cookie
+-   data.chocolate: "dark"
+~   data.cookie: "yummy" -> "yucky"
++   data.sugar: "brown"
+- Namespace cookie
++ Secret cookie/cookie
+
+`, out.String())
+		})
+
+		t.Run("markdown", func(t *testing.T) {
+			// given
+			out := &strings.Builder{}
+
+			// when
+			err := validation.WriteDiff(out, diffs, validation.DiffFormatMarkdown)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "### Application `cookie`\n\n```diff\n"+`# ConfigMap cookie/cookie
+-   data.chocolate: "dark"
+-   data.cookie: "yummy"
++   data.cookie: "yucky"
++   data.sugar: "brown"
+- Namespace cookie
++ Secret cookie/cookie
+`+"```\n\n", out.String())
+		})
+
+		t.Run("no changes", func(t *testing.T) {
+			// given
+			out := &strings.Builder{}
+
+			// when
+			err := validation.WriteDiff(out, []validation.ApplicationDiff{}, validation.DiffFormatText)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "no changes\n", out.String())
+		})
+
+		t.Run("invalid format", func(t *testing.T) {
+			// when
+			err := validation.WriteDiff(&strings.Builder{}, diffs, "html")
+
+			// then
+			require.EqualError(t, err, "invalid diff format: 'html'")
+		})
+	})
+
+	t.Run("secret values", func(t *testing.T) {
+		// given
+		oldApps := []validation.RenderedApplication{
+			newApp(t, "cookie", `apiVersion: v1
+kind: Secret
+metadata:
+  name: cookie
+data:
+  password: b2xkLXBhc3N3b3Jk
+  recipe: c2VjcmV0LXJlY2lwZQ==
+  token: b2xkLXRva2Vu
+stringData:
+  username: old-username`),
+		}
+		newApps := []validation.RenderedApplication{
+			newApp(t, "cookie", `apiVersion: v1
+kind: Secret
+metadata:
+  name: cookie
+data:
+  password: bmV3LXBhc3N3b3Jk
+  recipe: c2VjcmV0LXJlY2lwZQ==
+  salt: bmV3LXNhbHQ=
+stringData:
+  username: new-username`),
+		}
+
+		// when
+		diffs, err := validation.DiffApplications(oldApps, newApps)
+
+		// then
+		require.NoError(t, err)
+		// only the keys which were added, removed or modified are shown
+		require.Len(t, diffs, 1)
+		require.Len(t, diffs[0].Objects, 1)
+		paths := []string{}
+		for _, f := range diffs[0].Objects[0].Fields {
+			paths = append(paths, string(f.Type)+" "+f.Path)
+		}
+		assert.Equal(t, []string{
+			"modified data.password",
+			"added data.salt",
+			"removed data.token",
+			"modified stringData.username",
+		}, paths)
+		for _, format := range []validation.DiffFormat{validation.DiffFormatText, validation.DiffFormatMarkdown} {
+			out := &strings.Builder{}
+			err := validation.WriteDiff(out, diffs, format)
+			require.NoError(t, err)
+			for _, value := range []string{"b2xkLXBhc3N3b3Jk", "bmV3LXBhc3N3b3Jk", "c2VjcmV0LXJlY2lwZQ==", "b2xkLXRva2Vu", "bmV3LXNhbHQ=", "old-username", "new-username"} {
+				assert.NotContains(t, out.String(), value, "format: %s", format)
+			}
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package validation
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// Layout is the classification of the directories of a repository, relative to its base directory
+type Layout struct {
+	// Apps are the directories which contain Applications or ApplicationSets
+	Apps []string
+	// Components are the kustomization directories which are not referenced by other kustomizations
+	Components []string
+	// Bases are the kustomization directories which are referenced by other kustomizations. Their resources and
+	// references are checked, and they are built along with the components which reference them.
+	Bases []string
+}
+
+// Discover scans the base directory for Applications, ApplicationSets and kustomization files, and classifies
+// the directories as apps (including the kustomizations of Applications), components or bases. Nested directories are omitted when one of their parents has the
+// same classification, since the checks are recursive. Hidden directories and excluded paths are skipped.
+// An error is returned if nothing is found.
+func Discover(logger Logger, afs afero.Afero, opts Options, baseDir string) (Layout, error) {
+	apps := map[string]bool{}
+	kustomizations := map[string]bool{}
+	referenced := map[string]bool{}
+	if err := afs.Walk(baseDir, func(path string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != baseDir && (strings.HasPrefix(info.Name(), ".") || opts.excluded(baseDir, path)) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.IsDir() {
+			kp, found := lookupKustomizationFile(logger, afs, path)
+			if !found {
+				return nil
+			}
+			kustomizations[path] = true
+			data, err := afs.ReadFile(kp)
+			if err != nil {
+				return err
+			}
+			var kobj types.Kustomization
+			if err := kobj.Unmarshal(data); err != nil {
+				// reported when the kustomization is checked
+				return nil
+			}
+			for _, ref := range kustomizationReferences(kobj) {
+				referenced[filepath.Join(path, ref.path)] = true
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".yaml" || isKustomizationFile(path) {
+			return nil
+		}
+		data, err := afs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		objs, err := readObjects(data)
+		if err != nil {
+			return nil
+		}
+		for _, obj := range objs {
+			if sources, err := applicationSources(obj); err == nil && sources != nil {
+				apps[filepath.Dir(path)] = true
+				break
+			}
+		}
+		return nil
+	}); err != nil {
+		return Layout{}, err
+	}
+
+	layout := Layout{}
+	components := map[string]bool{}
+	for dir := range kustomizations {
+		if containsAny(dir, apps) {
+			// eg: a kustomization of Applications
+			apps[dir] = true
+		}
+	}
+	for dir := range kustomizations {
+		switch {
+		case referenced[dir]:
+			layout.Bases = append(layout.Bases, dir)
+		case within(dir, apps):
+			// checked along with the Applications
+		default:
+			components[dir] = true
+		}
+	}
+	for _, dirs := range []struct {
+		paths  map[string]bool
+		target *[]string
+	}{
+		{paths: apps, target: &layout.Apps},
+		{paths: components, target: &layout.Components},
+	} {
+		for dir := range dirs.paths {
+			if parentWithin(dir, dirs.paths) {
+				continue
+			}
+			*dirs.target = append(*dirs.target, dir)
+		}
+	}
+	for _, paths := range []*[]string{&layout.Apps, &layout.Components, &layout.Bases} {
+		for i, p := range *paths {
+			rel, err := filepath.Rel(baseDir, p)
+			if err != nil {
+				return Layout{}, err
+			}
+			(*paths)[i] = rel
+		}
+		sort.Strings(*paths)
+	}
+	if len(layout.Apps) == 0 && len(layout.Components) == 0 {
+		return layout, fmt.Errorf("no Applications, ApplicationSets or kustomizations found in %s", baseDir)
+	}
+	logger.Info("🔎 discovered layout", "apps", layout.Apps, "components", layout.Components, "bases", layout.Bases)
+	return layout, nil
+}
+
+// returns true if the given directory is one of the given directories, or is in one of them
+func within(dir string, dirs map[string]bool) bool {
+	return dirs[dir] || parentWithin(dir, dirs)
+}
+
+// returns true if one of the given directories is the given directory or is in it
+func containsAny(dir string, dirs map[string]bool) bool {
+	for d := range dirs {
+		if within(d, map[string]bool{dir: true}) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns true if one of the parents of the given directory is in the given directories
+func parentWithin(dir string, dirs map[string]bool) bool {
+	for d := range dirs {
+		if (d == "." && dir != ".") || strings.HasPrefix(dir, d+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
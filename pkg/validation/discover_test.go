@@ -0,0 +1,103 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscover(t *testing.T) {
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/argocd/apps/dev/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  source:
+    path: components/cookie/overlays/dev`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/argocd/apps/prod/cookies.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: cookies
+spec:
+  template:
+    spec:
+      source:
+        path: components/cookie/overlays/prod`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/argocd/apps/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- dev/cookie.yaml
+- prod/cookies.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1`)
+		require.NoError(t, err)
+		for _, env := range []string{"dev", "prod"} {
+			err = addFile(afs, "/path/to/components/cookie/overlays/"+env+"/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- ../../base`)
+			require.NoError(t, err)
+		}
+		err = addFile(afs, "/path/to/components/pasta/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/pasta/nested/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1`)
+		require.NoError(t, err)
+		// skipped
+		err = addFile(afs, "/path/to/.github/kustomization.yaml", `kind: Kustomization`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/experimental/kustomization.yaml", `kind: Kustomization`)
+		require.NoError(t, err)
+		opts := validation.Options{
+			Exclude: []string{"experimental"},
+		}
+
+		// when
+		layout, err := validation.Discover(logger, afs, opts, "/path/to")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, validation.Layout{
+			Apps:       []string{"argocd/apps"},
+			Components: []string{"components/cookie/overlays/dev", "components/cookie/overlays/prod", "components/pasta"},
+			Bases:      []string{"components/cookie/base"},
+		}, layout)
+	})
+
+	t.Run("nothing found", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/README.md", `nothing to see here`)
+		require.NoError(t, err)
+
+		// when
+		_, err = validation.Discover(logger, afs, validation.Options{}, "/path/to")
+
+		// then
+		require.EqualError(t, err, "no Applications, ApplicationSets or kustomizations found in /path/to")
+	})
+}
@@ -0,0 +1,143 @@
+package validation
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Finding is an issue reported by a rule
+type Finding struct {
+	// RuleID is the ID of the rule which reported the finding
+	RuleID string
+	// Severity is the effective severity of the finding, after the configuration was applied
+	Severity Severity
+	// Path is the file or directory in which the issue was found
+	Path string
+	// Message describes the issue
+	Message string
+	// KeyVals are additional key/value pairs about the issue (eg: the name of the unreferenced resource)
+	KeyVals []interface{}
+	// Suggestion is an optional hint to fix the issue
+	Suggestion string
+}
+
+// reporter logs the findings of the enabled rules, and keeps track of them
+type reporter struct {
+	logger   Logger
+	rules    *Registry
+	strict   bool
+	ignored  map[string]bool
+	findings *[]Finding
+}
+
+func newReporter(logger Logger, opts Options) *reporter {
+	rules := opts.Rules
+	if rules == nil {
+		rules = NewRegistry()
+	}
+	return &reporter{
+		logger:   logger,
+		rules:    rules,
+		strict:   opts.Strict,
+		ignored:  map[string]bool{},
+		findings: &[]Finding{},
+	}
+}
+
+// returns a reporter which ignores the findings of the given rules (IDs or names), in addition to the rules
+// already ignored by this reporter
+func (r *reporter) ignoring(rules ...string) *reporter {
+	if len(rules) == 0 {
+		return r
+	}
+	ignored := make(map[string]bool, len(r.ignored)+len(rules))
+	for id := range r.ignored {
+		ignored[id] = true
+	}
+	for _, id := range rules {
+		ignored[id] = true
+	}
+	return &reporter{
+		logger:   r.logger,
+		rules:    r.rules,
+		strict:   r.strict,
+		ignored:  ignored,
+		findings: r.findings,
+	}
+}
+
+// returns true if the given rule is enabled and not ignored
+func (r *reporter) enabled(rl Rule) bool {
+	return r.rules.Enabled(rl) && !r.ignored[rl.ID()] && !r.ignored[rl.Name()]
+}
+
+// report logs the given finding with the level matching the severity of the rule.
+// In strict mode, all findings have the `error` severity.
+func (r *reporter) report(rl Rule, f Finding) {
+	if !r.enabled(rl) {
+		return
+	}
+	f.RuleID = rl.ID()
+	f.Severity = r.rules.Severity(rl)
+	if r.strict {
+		f.Severity = SeverityError
+	}
+	keyvals := append([]interface{}{"rule", f.RuleID, "path", f.Path}, f.KeyVals...)
+	if f.Suggestion != "" {
+		keyvals = append(keyvals, "suggestion", f.Suggestion)
+	}
+	switch f.Severity {
+	case SeverityError:
+		r.logger.Error(f.Message, keyvals...)
+	default:
+		r.logger.Warn(f.Message, keyvals...)
+	}
+	*r.findings = append(*r.findings, f)
+}
+
+// checks the rendered objects with all the enabled ResourcesRules
+func (r *reporter) checkResources(path string, objs []*yaml.RNode) error {
+	for _, rl := range r.rules.Rules() {
+		if rr, ok := rl.(ResourcesRule); ok && r.enabled(rl) {
+			findings, err := rr.CheckResources(path, objs)
+			if err != nil {
+				return err
+			}
+			for _, f := range findings {
+				r.report(rl, f)
+			}
+		}
+	}
+	return nil
+}
+
+// checks the Applications and ApplicationSets with all the enabled ApplicationsRules
+func (r *reporter) checkApplications(path string, objs []*yaml.RNode) error {
+	for _, rl := range r.rules.Rules() {
+		if ar, ok := rl.(ApplicationsRule); ok && r.enabled(rl) {
+			findings, err := ar.CheckApplications(path, objs)
+			if err != nil {
+				return err
+			}
+			for _, f := range findings {
+				r.report(rl, f)
+			}
+		}
+	}
+	return nil
+}
+
+// returns an error if some findings have the `error` severity
+func (r *reporter) err() error {
+	errs := 0
+	for _, f := range *r.findings {
+		if f.Severity == SeverityError {
+			errs++
+		}
+	}
+	if errs > 0 {
+		return fmt.Errorf("found %d error(s)", errs)
+	}
+	return nil
+}
@@ -9,12 +9,23 @@ import (
 
 func NewInMemoryFS(logger Logger, afs afero.Afero, baseDir string) (kfsys.FileSystem, error) {
 	fsys := kfsys.MakeFsInMemory()
-	if err := afs.Walk(baseDir,
+	if err := addToFS(logger, afs, fsys, baseDir); err != nil {
+		return nil, err
+	}
+	return fsys, nil
+}
+
+// copies the contents of the given directory into the in-memory filesystem (except the `.git` directory)
+func addToFS(logger Logger, afs afero.Afero, fsys kfsys.FileSystem, baseDir string) error {
+	return afs.Walk(baseDir,
 		func(path string, info iofs.FileInfo, err error) error {
 			if err != nil {
 				logger.Error("prevent panic by handling failure", "path", path, "err", err)
 				return err
 			}
+			if info.IsDir() && info.Name() == ".git" {
+				return iofs.SkipDir
+			}
 			if info.IsDir() {
 				logger.Debug("adding directory in fsys", "path", path)
 				return fsys.Mkdir(path)
@@ -26,8 +37,5 @@ func NewInMemoryFS(logger Logger, afs afero.Afero, baseDir string) (kfsys.FileSy
 			logger.Debug("adding file in fsys", "path", path)
 			return fsys.WriteFile(path, data)
 		},
-	); err != nil {
-		return nil, err
-	}
-	return fsys, nil
+	)
 }
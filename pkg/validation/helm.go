@@ -0,0 +1,162 @@
+package validation
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/types"
+	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// returns true if the Helm chart inflation is enabled, either with the `EnableHelm` option or with
+// the `--enable-helm` build option
+func (o Options) helmEnabled() bool {
+	if o.EnableHelm {
+		return true
+	}
+	for _, opt := range o.BuildOptions {
+		if opt == "--enable-helm" || opt == "--enable-helm=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the build options, including `--enable-helm` if the Helm chart inflation is enabled
+func (o Options) buildOptions() []string {
+	if o.EnableHelm {
+		return append([]string{"--enable-helm"}, o.BuildOptions...)
+	}
+	return o.BuildOptions
+}
+
+// returns the directory in which the Helm charts of the given kustomization are looked up: the chart home
+// set in the options, or the `helmGlobals.chartHome` of the kustomization (`charts` by default)
+func chartHome(opts Options, path string, kobj types.Kustomization) string {
+	if opts.HelmChartHome != "" {
+		return opts.HelmChartHome
+	}
+	home := types.HelmDefaultHome
+	if kobj.HelmGlobals != nil && kobj.HelmGlobals.ChartHome != "" {
+		home = kobj.HelmGlobals.ChartHome
+	}
+	if filepath.IsAbs(home) {
+		return home
+	}
+	return filepath.Join(filepath.Dir(path), home)
+}
+
+// verifies that the Helm charts of the kustomization are available in the local chart home, since they are never
+// pulled from their repository
+func checkHelmCharts(logger Logger, r *reporter, afs afero.Afero, opts Options, path string, kobj types.Kustomization) error {
+	if !opts.helmEnabled() || len(kobj.HelmCharts) == 0 {
+		return nil
+	}
+	home := chartHome(opts, path, kobj)
+	for _, c := range kobj.HelmCharts {
+		logger.Debug("checking Helm chart", "path", path, "chart", c.Name, "chartHome", home)
+		exists, err := afs.DirExists(filepath.Join(home, c.Name))
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		f := Finding{
+			Path:    path,
+			Message: "Helm chart not found in the local chart home",
+			KeyVals: []interface{}{"chart", c.Name, "version", c.Version, "chartHome", home},
+		}
+		if c.Repo != "" {
+			f.Suggestion = fmt.Sprintf("helm pull %s --repo %s --version '%s' --untar --untardir %s", c.Name, c.Repo, c.Version, home)
+		}
+		r.report(MissingHelmChartRule, f)
+	}
+	return nil
+}
+
+// rewrites the kustomizations of the in-memory filesystem so that `kustomize build` inflates the Helm charts
+// from the local chart home (which is added in the in-memory filesystem), without pulling them. The kustomizations
+// of the vendored remote resources are also rewritten.
+func resolveHelmCharts(logger Logger, afs afero.Afero, fsys kfsys.FileSystem, opts Options, baseDir string) error {
+	if !opts.helmEnabled() {
+		return nil
+	}
+	if opts.HelmChartHome != "" {
+		if err := addToFS(logger, afs, fsys, opts.HelmChartHome); err != nil {
+			return err
+		}
+	}
+	dirs := []string{baseDir}
+	for _, v := range opts.VendoredResources {
+		dirs = append(dirs, v.Path)
+	}
+	for _, dir := range dirs {
+		if !fsys.Exists(dir) {
+			continue
+		}
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		kustomizations, err := lookupKustomizationFiles(fsys, dir)
+		if err != nil {
+			return err
+		}
+		for _, path := range kustomizations {
+			data, err := fsys.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var kobj types.Kustomization
+			if err := kobj.Unmarshal(data); err != nil || len(kobj.HelmCharts) == 0 {
+				// invalid kustomizations are reported when they are checked
+				continue
+			}
+			// the `helm` binary reads the charts from the OS filesystem, whose paths differ from the paths of the
+			// in-memory filesystem if the base directory is relative
+			rel, err := filepath.Rel(inMemoryPath(dir), path)
+			if err != nil {
+				return err
+			}
+			home, err := filepath.Abs(chartHome(opts, filepath.Join(absDir, rel), kobj))
+			if err != nil {
+				return err
+			}
+			inMemoryHome := inMemoryPath(chartHome(opts, path, kobj))
+			if kobj.HelmGlobals == nil {
+				kobj.HelmGlobals = &types.HelmGlobals{}
+			}
+			kobj.HelmGlobals.ChartHome = home
+			for i, c := range kobj.HelmCharts {
+				// no repo, no pull
+				kobj.HelmCharts[i].Repo = ""
+				if c.ValuesFile == "" {
+					// the values file is read from the in-memory filesystem, relative to the kustomization
+					values := filepath.Join(inMemoryHome, c.Name, "values.yaml")
+					if kobj.HelmCharts[i].ValuesFile, err = filepath.Rel(filepath.Dir(path), values); err != nil {
+						return err
+					}
+				}
+			}
+			logger.Debug("resolving Helm charts from the local chart home", "path", path)
+			if data, err = k8syaml.Marshal(kobj); err != nil {
+				return err
+			}
+			if err := fsys.WriteFile(path, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// returns the given path as resolved by the in-memory filesystem, where the relative paths are relative to the root
+func inMemoryPath(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(string(filepath.Separator), path)
+}
@@ -0,0 +1,221 @@
+package validation_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelmCharts(t *testing.T) {
+
+	// the kustomization is in a `base` directory, so `kustomize build` (which requires the `helm` binary) is skipped
+	newFS := func(t *testing.T) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/cookie/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+helmCharts:
+- name: cookie
+  repo: https://charts.example.com
+  version: 1.0.0
+  releaseName: cookie`)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+
+		t.Run("chart in default chart home", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+			err := addFile(afs, "/path/to/components/cookie/base/charts/cookie/Chart.yaml", `name: cookie
+version: 1.0.0`)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{EnableHelm: true}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+
+		t.Run("chart in custom chart home", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+			err := addFile(afs, "/path/to/charts/cookie/Chart.yaml", `name: cookie
+version: 1.0.0`)
+			require.NoError(t, err)
+			opts := validation.Options{
+				BuildOptions:  []string{"--enable-helm"},
+				HelmChartHome: "/path/to/charts",
+			}
+
+			// when
+			err = validation.CheckComponents(logger, afs, opts, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+
+		t.Run("inflation with a relative base directory", func(t *testing.T) {
+			// given
+			if runtime.GOOS == "windows" {
+				t.Skip("the stub of the helm binary is a shell script")
+			}
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewOsFs(),
+			}
+			tmpDir := t.TempDir()
+			addFile := func(afs afero.Afero, path string, data string) error {
+				if err := afs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					return err
+				}
+				return addFile(afs, path, data)
+			}
+			// stub of the helm binary, which renders a single ConfigMap
+			err := addFile(afs, filepath.Join(tmpDir, "bin", "helm"), `#!/bin/sh
+case "$1" in
+version) echo "v3.13.0" ;;
+template) printf 'apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cookie\ndata:\n  cookie: yummy\n' ;;
+*) echo "unexpected helm command: $*" >&2; exit 1 ;;
+esac`)
+			require.NoError(t, err)
+			t.Setenv("PATH", filepath.Join(tmpDir, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
+			err = addFile(afs, filepath.Join(tmpDir, "repo", "apps", "cookie.yaml"), `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  source:
+    path: components/cookie`)
+			require.NoError(t, err)
+			err = addFile(afs, filepath.Join(tmpDir, "repo", "components", "cookie", "kustomization.yaml"), `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+helmCharts:
+- name: cookie
+  repo: https://charts.example.com
+  version: 1.0.0
+  releaseName: cookie`)
+			require.NoError(t, err)
+			err = addFile(afs, filepath.Join(tmpDir, "repo", "components", "cookie", "charts", "cookie", "Chart.yaml"), `name: cookie
+version: 1.0.0`)
+			require.NoError(t, err)
+			err = addFile(afs, filepath.Join(tmpDir, "repo", "components", "cookie", "charts", "cookie", "values.yaml"), `cookie: yummy`)
+			require.NoError(t, err)
+			wd, err := os.Getwd()
+			require.NoError(t, err)
+			require.NoError(t, os.Chdir(tmpDir))
+			t.Cleanup(func() {
+				require.NoError(t, os.Chdir(wd))
+			})
+
+			// when
+			rendered, err := validation.RenderApplications(logger, afs, validation.Options{EnableHelm: true}, "repo", "apps")
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, rendered, 1)
+			require.Len(t, rendered[0].Objects, 1)
+			assert.Equal(t, "ConfigMap", rendered[0].Objects[0].GetKind())
+			assert.Equal(t, "cookie", rendered[0].Objects[0].GetName())
+
+			t.Run("check", func(t *testing.T) {
+				// when
+				err := validation.CheckComponents(logger, afs, validation.Options{EnableHelm: true}, "repo", "components")
+
+				// then
+				require.NoError(t, err)
+				assert.Empty(t, logger.Errors())
+			})
+		})
+
+		t.Run("helm disabled", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("missing chart", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{EnableHelm: true}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "Helm chart not found in the local chart home",
+					KeyVals: []interface{}{
+						"rule", "ACK007",
+						"path", "/path/to/components/cookie/base/kustomization.yaml",
+						"chart", "cookie",
+						"version", "1.0.0",
+						"chartHome", "/path/to/components/cookie/base/charts",
+						"suggestion", "helm pull cookie --repo https://charts.example.com --version '1.0.0' --untar --untardir /path/to/components/cookie/base/charts",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("missing chart in custom chart home", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+			err := afs.MkdirAll("/path/to/charts", 0755)
+			require.NoError(t, err)
+			opts := validation.Options{
+				EnableHelm:    true,
+				HelmChartHome: "/path/to/charts",
+			}
+
+			// when
+			err = validation.CheckComponents(logger, afs, opts, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Errors(), 1)
+			assert.Contains(t, logger.Errors()[0].KeyVals, "/path/to/charts")
+		})
+	})
+}
@@ -0,0 +1,256 @@
+package validation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// DefaultRegistry is the registry of the images whose name has no registry (eg: `nginx:1.25`)
+const DefaultRegistry = "docker.io"
+
+// paths of the pod spec in the workloads, indexed by kind
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// fields of the containers in a pod spec
+var containerFields = []string{"initContainers", "containers", "ephemeralContainers"}
+
+// returns the pod spec of the given workload, if any
+func podSpec(obj *yaml.RNode) (*yaml.RNode, bool) {
+	path, found := podSpecPaths[obj.GetKind()]
+	if !found {
+		return nil, false
+	}
+	spec, err := obj.Pipe(yaml.Lookup(path...))
+	if err != nil || spec == nil {
+		return nil, false
+	}
+	return spec, true
+}
+
+// returns the containers of the given pod spec, including the init and ephemeral containers
+func podContainers(spec *yaml.RNode) []*yaml.RNode {
+	containers := []*yaml.RNode{}
+	for _, field := range containerFields {
+		f := spec.Field(field)
+		if f == nil {
+			continue
+		}
+		elements, err := f.Value.Elements()
+		if err != nil {
+			continue
+		}
+		containers = append(containers, elements...)
+	}
+	return containers
+}
+
+// ContainerImage is the image of a container in a rendered workload
+type ContainerImage struct {
+	// Kind of the workload (eg: `Deployment`)
+	Kind string
+	// Namespace of the workload
+	Namespace string
+	// Name of the workload
+	Name string
+	// Container is the name of the container
+	Container string
+	// Image is the image reference of the container
+	Image string
+}
+
+// Images returns the images of the containers (including the init and ephemeral containers) of the given workloads:
+// Pods, Deployments, StatefulSets, DaemonSets, ReplicaSets, Jobs and CronJobs
+func Images(objs []*yaml.RNode) []ContainerImage {
+	images := []ContainerImage{}
+	for _, obj := range objs {
+		spec, found := podSpec(obj)
+		if !found {
+			continue
+		}
+		for _, c := range podContainers(spec) {
+			name, _ := c.GetString("name")
+			image, _ := c.GetString("image")
+			images = append(images, ContainerImage{
+				Kind:      obj.GetKind(),
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+				Container: name,
+				Image:     image,
+			})
+		}
+	}
+	return images
+}
+
+// WriteImages writes the images of the containers of each given Application, as a table
+func WriteImages(w io.Writer, apps []RenderedApplication) error {
+	for i, app := range apps {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "Application %s (%s)\n", app.Name, app.Path); err != nil {
+			return err
+		}
+		images := Images(app.Objects)
+		sort.SliceStable(images, func(i, j int) bool {
+			return images[i].Image < images[j].Image
+		})
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "IMAGE\tKIND\tNAMESPACE\tNAME\tCONTAINER")
+		for _, img := range images {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", img.Image, img.Kind, img.Namespace, img.Name, img.Container)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imageReference is a parsed image reference: `[registry/]repository[:tag][@digest]`
+type imageReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parses the given image reference. The registry is the first component of the name if it looks like a host
+// (ie: with a `.` or a `:`, or `localhost`), the default registry otherwise.
+func parseImage(image string) imageReference {
+	ref := imageReference{}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i:], "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+	ref.registry = DefaultRegistry
+	if i := strings.Index(name, "/"); i >= 0 {
+		if host := name[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.registry, name = host, name[i+1:]
+		}
+	}
+	ref.repository = name
+	return ref
+}
+
+// returns the name of the image, without its tag and digest (eg: `quay.io/org/app`)
+func (r imageReference) name() string {
+	return r.registry + "/" + r.repository
+}
+
+// returns true if the name of the image is in one of the given registries, which may include a path
+// (eg: `quay.io` or `quay.io/org`)
+func (r imageReference) allowed(registries []string) bool {
+	name := r.name()
+	if r.registry == DefaultRegistry && !strings.Contains(r.repository, "/") {
+		// eg: `nginx` is `docker.io/library/nginx`
+		name = r.registry + "/library/" + r.repository
+	}
+	for _, reg := range registries {
+		reg = strings.TrimSuffix(reg, "/")
+		if name == reg || strings.HasPrefix(name, reg+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// reports the images of the rendered workloads which are untagged or use the `latest` tag, and the images which are
+// not in the allowed registries (if any)
+func checkImages(r *reporter, opts Options, path string, objs []*yaml.RNode) {
+	for _, img := range Images(objs) {
+		if img.Image == "" {
+			continue
+		}
+		keyVals := []interface{}{"kind", img.Kind, "name", img.Name, "container", img.Container, "image", img.Image}
+		ref := parseImage(img.Image)
+		switch {
+		case ref.digest != "":
+		case ref.tag == "":
+			r.report(UnpinnedImageRule, Finding{
+				Path:       path,
+				Message:    "image has no tag",
+				KeyVals:    keyVals,
+				Suggestion: "use a tag or a digest which identifies a single version of the image",
+			})
+		case ref.tag == "latest":
+			r.report(UnpinnedImageRule, Finding{
+				Path:       path,
+				Message:    "image uses the 'latest' tag",
+				KeyVals:    keyVals,
+				Suggestion: "use a tag or a digest which identifies a single version of the image",
+			})
+		}
+		if len(opts.AllowedRegistries) > 0 && !ref.allowed(opts.AllowedRegistries) {
+			r.report(DisallowedRegistryRule, Finding{
+				Path:    path,
+				Message: "image is not in the allowed registries",
+				KeyVals: append(keyVals, "registry", ref.registry),
+			})
+		}
+	}
+}
+
+// reports the entries of the `images` transformer of the given kustomization which don't match the images of the
+// rendered workloads: entries whose image is not in the output (eg: a misspelled name), and rendered images with the
+// name of an entry but another tag or digest
+func checkImageOverrides(r *reporter, path string, overrides []types.Image, objs []*yaml.RNode) {
+	rendered := map[string][]string{}
+	repositories := []string{}
+	for _, img := range Images(objs) {
+		ref := parseImage(img.Image)
+		if _, found := rendered[ref.name()]; !found {
+			repositories = append(repositories, ref.repository)
+		}
+		rendered[ref.name()] = append(rendered[ref.name()], img.Image)
+	}
+	sort.Strings(repositories)
+	for _, o := range overrides {
+		name := o.Name
+		if o.NewName != "" {
+			name = o.NewName
+		}
+		images, found := rendered[parseImage(name).name()]
+		if !found {
+			f := Finding{
+				Path:    path,
+				Message: "images entry doesn't match any image of the rendered workloads",
+				KeyVals: []interface{}{"name", o.Name},
+			}
+			if s, found := closest(parseImage(o.Name).repository, repositories); found {
+				f.Suggestion = fmt.Sprintf("name: %s", s)
+			}
+			r.report(ImageOverrideMismatchRule, f)
+			continue
+		}
+		for _, image := range sortedKeys(toSet(images)) {
+			ref := parseImage(image)
+			if (o.Digest != "" && ref.digest != o.Digest) || (o.Digest == "" && o.NewTag != "" && ref.tag != o.NewTag) {
+				r.report(ImageOverrideMismatchRule, Finding{
+					Path:    path,
+					Message: "rendered image doesn't match the images entry",
+					KeyVals: []interface{}{"name", o.Name, "image", image},
+				})
+			}
+		}
+	}
+}
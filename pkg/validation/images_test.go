@@ -0,0 +1,309 @@
+package validation_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestCheckImages(t *testing.T) {
+
+	newFS := func(t *testing.T, images, workloads string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- workloads.yaml
+`+images)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/workloads.yaml", workloads)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `images:
+- name: cookie
+  newName: quay.io/org/cookie
+  newTag: v1.2.3
+- name: nginx
+  digest: sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08`, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cookie
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: nginx
+      containers:
+      - name: cookie
+        image: cookie:latest
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: registry.k8s.io/kubectl:v1.28.0`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{
+			AllowedRegistries: []string{"quay.io/org", "registry.k8s.io", "docker.io/library"},
+		}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("unpinned images", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, "", `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: cookie
+spec:
+  template:
+    spec:
+      containers:
+      - name: cookie
+        image: quay.io/org/cookie
+      - name: proxy
+        image: localhost:5000/proxy:latest`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "image has no tag",
+					KeyVals: []interface{}{
+						"rule", "ACK022",
+						"path", "/path/to/components/cookie",
+						"kind", "StatefulSet",
+						"name", "cookie",
+						"container", "cookie",
+						"image", "quay.io/org/cookie",
+						"suggestion", "use a tag or a digest which identifies a single version of the image",
+					},
+				},
+				{
+					Msg: "image uses the 'latest' tag",
+					KeyVals: []interface{}{
+						"rule", "ACK022",
+						"path", "/path/to/components/cookie",
+						"kind", "StatefulSet",
+						"name", "cookie",
+						"container", "proxy",
+						"image", "localhost:5000/proxy:latest",
+						"suggestion", "use a tag or a digest which identifies a single version of the image",
+					},
+				},
+			}, logger.Warnings())
+		})
+
+		t.Run("disallowed registries", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, "", `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: cookie
+spec:
+  template:
+    spec:
+      containers:
+      - name: cookie
+        image: quay.io/other/cookie:v1.2.3
+      - name: nginx
+        image: nginx:1.25`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{
+				AllowedRegistries: []string{"quay.io/org"},
+			}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 2 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "image is not in the allowed registries",
+					KeyVals: []interface{}{
+						"rule", "ACK023",
+						"path", "/path/to/components/cookie",
+						"kind", "DaemonSet",
+						"name", "cookie",
+						"container", "cookie",
+						"image", "quay.io/other/cookie:v1.2.3",
+						"registry", "quay.io",
+					},
+				},
+				{
+					Msg: "image is not in the allowed registries",
+					KeyVals: []interface{}{
+						"rule", "ACK023",
+						"path", "/path/to/components/cookie",
+						"kind", "DaemonSet",
+						"name", "cookie",
+						"container", "nginx",
+						"image", "nginx:1.25",
+						"registry", "docker.io",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("images transformer mismatch", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `images:
+- name: quay.io/org/cooky
+  newTag: v1.2.3
+- name: nginx
+  newTag: "1.25"
+- name: proxy
+  newName: nginx
+  newTag: "1.24"`, `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: cookie
+spec:
+  template:
+    spec:
+      containers:
+      - name: cookie
+        image: quay.io/org/cookie:v1.0.0
+      - name: nginx
+        image: nginx:1.23
+      - name: proxy
+        image: proxy:1.23`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 3 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "images entry doesn't match any image of the rendered workloads",
+					KeyVals: []interface{}{
+						"rule", "ACK024",
+						"path", "/path/to/components/cookie/kustomization.yaml",
+						"name", "quay.io/org/cooky",
+						"suggestion", "name: org/cookie",
+					},
+				},
+				{
+					Msg: "rendered image doesn't match the images entry",
+					KeyVals: []interface{}{
+						"rule", "ACK024",
+						"path", "/path/to/components/cookie/kustomization.yaml",
+						"name", "nginx",
+						"image", "nginx:1.24",
+					},
+				},
+				{
+					Msg: "rendered image doesn't match the images entry",
+					KeyVals: []interface{}{
+						"rule", "ACK024",
+						"path", "/path/to/components/cookie/kustomization.yaml",
+						"name", "proxy",
+						"image", "nginx:1.25",
+					},
+				},
+			}, logger.Errors())
+		})
+	})
+}
+
+func TestWriteImages(t *testing.T) {
+	// given
+	deployment, err := yaml.Parse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cookie
+  namespace: cookie
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: quay.io/org/init:v1.0.0
+      containers:
+      - name: cookie
+        image: quay.io/org/cookie:v1.2.3`)
+	require.NoError(t, err)
+	cronJob, err := yaml.Parse(`apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+  namespace: cookie
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: cleanup
+            image: registry.k8s.io/kubectl:v1.28.0`)
+	require.NoError(t, err)
+	configMap, err := yaml.Parse(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+  namespace: cookie`)
+	require.NoError(t, err)
+	out := &strings.Builder{}
+
+	// when
+	err = validation.WriteImages(out, []validation.RenderedApplication{
+		{
+			Name:    "cookie",
+			Path:    "/path/to/apps/cookie.yaml",
+			Objects: []*yaml.RNode{deployment, configMap, cronJob},
+		},
+	})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, `Application cookie (/path/to/apps/cookie.yaml)
+IMAGE                            KIND        NAMESPACE  NAME     CONTAINER
+quay.io/org/cookie:v1.2.3        Deployment  cookie     cookie   cookie
+quay.io/org/init:v1.0.0          Deployment  cookie     cookie   init
+registry.k8s.io/kubectl:v1.28.0  CronJob     cookie     cleanup  cleanup
+`, out.String())
+}
@@ -4,14 +4,29 @@ import (
 	"bytes"
 	"os/exec"
 	"path/filepath"
+	"sync"
 
 	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/types"
 	kbuild "sigs.k8s.io/kustomize/kustomize/v5/commands/build"
 	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+func isKustomizationFile(path string) bool {
+	for _, k := range kustomizationFiles {
+		if filepath.Base(path) == k {
+			return true
+		}
+	}
+	return false
+}
+
 func lookupKustomizationFile(logger Logger, afs afero.Afero, basedir string) (string, bool) {
-	for _, k := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+	for _, k := range kustomizationFiles {
 		p := filepath.Join(basedir, k)
 		if _, err := afs.Open(p); err == nil {
 			logger.Debug("found Kustomization file", "path", p)
@@ -21,16 +36,97 @@ func lookupKustomizationFile(logger Logger, afs afero.Afero, basedir string) (st
 	return "", false
 }
 
-// verifies that `kustomize build` completes successfully
-func checkBuild(logger Logger, fsys kfsys.FileSystem, path string) error {
-	logger.Debug("👀 checking kustomize build", "path", path)
+// checks the Kustomization file in the given directory (if any), and verifies that `kustomize build` completes
+// successfully, unless the directory is a `base`. The rules listed in `# argocd-checker:ignore` comments of the
+// Kustomization file are ignored.
+func checkKustomization(logger Logger, r *reporter, afs afero.Afero, fsys kfsys.FileSystem, opts Options, dir string) error {
+	kp, found := lookupKustomizationFile(logger, afs, dir)
+	if !found {
+		return nil
+	}
+	data, err := afs.ReadFile(kp)
+	if err != nil {
+		return err
+	}
+	ignored := ignoredRules(data)
+	r = r.ignoring(ignored...)
+	checkIgnoredRules(r, kp, ignored)
+	var kobj types.Kustomization
+	if err := kobj.Unmarshal(data); err != nil {
+		return err
+	}
+	if err := checkKustomizeResources(logger, r, afs, kp, kobj); err != nil {
+		return err
+	}
+	if err := checkKustomizeReferences(logger, r, afs, kp, kobj); err != nil {
+		return err
+	}
+	if err := checkRemoteResources(logger, r, afs, opts, kp, kobj); err != nil {
+		return err
+	}
+	if err := checkHelmCharts(logger, r, afs, opts, kp, kobj); err != nil {
+		return err
+	}
+	checkKSOPSGenerators(r, afs, opts, kp, kobj)
+	if filepath.Base(dir) == "base" {
+		return nil
+	}
+	objs, err := checkBuild(logger, fsys, opts, dir)
+	if err != nil {
+		r.report(KustomizeBuildRule, Finding{
+			Path:    dir,
+			Message: "kustomize build failed",
+			KeyVals: []interface{}{"err", err},
+		})
+		return nil
+	}
+	checkImageOverrides(r, kp, kobj.Images, objs)
+	return checkRenderedObjects(logger, r, opts, dir, objs)
+}
+
+// checks the objects rendered by `kustomize build` in the given directory, with the built-in rules and with the
+// enabled ResourcesRules
+func checkRenderedObjects(_ Logger, r *reporter, opts Options, dir string, objs []*yaml.RNode) error {
+	checkSyncWaves(r, dir, objs)
+	checkArgoCDAnnotations(r, dir, objs)
+	checkSecrets(r, dir, objs)
+	checkImages(r, opts, dir, objs)
+	checkWorkloads(r, dir, objs)
+	checkCrossReferences(r, opts, dir, objs)
+	if !opts.sources.versionedOnly(dir) {
+		// the Applications deployed to clusters with a known Kubernetes version are verified against their version
+		checkDeprecatedAPIs(r, opts.KubeVersion, dir, objs)
+	}
+	return r.checkResources(dir, objs)
+}
+
+// buildMutex serializes the `kustomize build` commands, whose flags are global variables
+var buildMutex sync.Mutex
+
+// verifies that `kustomize build` completes successfully and returns the rendered objects
+func checkBuild(logger Logger, fsys kfsys.FileSystem, opts Options, path string) ([]*yaml.RNode, error) {
+	logger.Debug("👀 checking kustomize build", "path", path, "options", opts.buildOptions())
 	buffy := new(bytes.Buffer)
 
+	buildMutex.Lock()
+	defer buildMutex.Unlock()
+
 	kcmd := kbuild.NewCmdBuild(fsys, &kbuild.Help{}, buffy)
+	if err := kcmd.Flags().Parse(opts.buildOptions()); err != nil {
+		return nil, err
+	}
 	if err := kcmd.RunE(kcmd, []string{path}); err != nil {
-		return err
+		return nil, err
 	}
 
 	exec.Command("kustomize", "build")
-	return nil
+	return readObjects(buffy.Bytes())
+}
+
+// splits the given YAML stream into objects
+func readObjects(data []byte) ([]*yaml.RNode, error) {
+	return (&kio.ByteReader{
+		Reader:                bytes.NewReader(data),
+		OmitReaderAnnotations: true,
+	}).Read()
 }
@@ -0,0 +1,192 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	argocdv1alpha1 "github.com/codeready-toolchain/argocd-checker/pkg/argocd-types/application/v1alpha1"
+
+	kvalidation "k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// DefaultArgoCDNamespace is the default namespace of the Applications and ApplicationSets
+const DefaultArgoCDNamespace = "argocd"
+
+// ResourcesFinalizer is the finalizer which makes Argo CD delete the resources of an Application when it is deleted
+const ResourcesFinalizer = "resources-finalizer.argocd.argoproj.io"
+
+// returns the namespace in which the Applications and ApplicationSets must be
+func (o Options) argocdNamespace() string {
+	if o.ArgoCDNamespace != "" {
+		return o.ArgoCDNamespace
+	}
+	return DefaultArgoCDNamespace
+}
+
+// applicationNames keeps track of the names of the Applications (including the ones generated by the ApplicationSets)
+// per namespace, to report the duplicates across all the scanned files
+type applicationNames map[string]string
+
+// registers the given Application name, and reports it if it is already registered
+func (n applicationNames) register(r *reporter, path, kind, namespace, name string) {
+	key := namespace + "/" + name
+	if other, found := n[key]; found {
+		r.report(DuplicateApplicationRule, Finding{
+			Path:    path,
+			Message: "Application name is not unique in its namespace",
+			KeyVals: []interface{}{"kind", kind, "name", name, "namespace", namespace, "duplicate", other},
+		})
+		return
+	}
+	n[key] = path
+}
+
+// verifies the metadata of the given Application or ApplicationSet: the name, the namespace and the finalizers.
+// The names of the Applications (including the ones generated by the ApplicationSets with list generators) are
+// registered to detect the duplicates.
+func checkApplicationMetadata(logger Logger, r *reporter, opts Options, names applicationNames, path string, obj *yaml.RNode) error {
+	kind := obj.GetKind()
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		// eg: set by the kustomization of the Applications
+		namespace = opts.argocdNamespace()
+	} else if namespace != opts.argocdNamespace() {
+		r.report(ApplicationNamespaceRule, Finding{
+			Path:    path,
+			Message: "Application is not in the Argo CD namespace",
+			KeyVals: []interface{}{"kind", kind, "name", obj.GetName(), "namespace", namespace, "expected", opts.argocdNamespace()},
+		})
+	}
+	checkName(r, path, kind, obj.GetName())
+
+	switch kind {
+	case "Application":
+		app := &argocdv1alpha1.Application{}
+		if err := decode(obj, app); err != nil {
+			return err
+		}
+		names.register(r, path, kind, namespace, obj.GetName())
+		checkFinalizers(r, path, kind, obj.GetName(), app.Finalizers)
+	case "ApplicationSet":
+		appSet := &argocdv1alpha1.ApplicationSet{}
+		if err := decode(obj, appSet); err != nil {
+			return err
+		}
+		checkFinalizers(r, path, kind, obj.GetName(), appSet.Spec.Template.Finalizers)
+		if appSet.Spec.Template.Namespace != "" {
+			namespace = appSet.Spec.Template.Namespace
+		}
+		generated, err := generatedNames(appSet)
+		if err != nil {
+			return fmt.Errorf("invalid ApplicationSet in %s: %w", path, err)
+		}
+		for _, name := range generated {
+			logger.Debug("checking generated Application name", "path", path, "applicationSet", obj.GetName(), "name", name)
+			checkName(r, path, "Application", name)
+			names.register(r, path, "Application", namespace, name)
+		}
+	}
+	return nil
+}
+
+// reports the given name if it is not a valid DNS-1123 subdomain
+func checkName(r *reporter, path, kind, name string) {
+	if errs := kvalidation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		r.report(InvalidApplicationNameRule, Finding{
+			Path:    path,
+			Message: "name is not a valid DNS-1123 subdomain",
+			KeyVals: []interface{}{"kind", kind, "name", name, "err", strings.Join(errs, ", ")},
+		})
+	}
+}
+
+// reports the Applications (and the templates of ApplicationSets) without the resources finalizer
+func checkFinalizers(r *reporter, path, kind, name string, finalizers []string) {
+	for _, f := range finalizers {
+		if f == ResourcesFinalizer || strings.HasPrefix(f, ResourcesFinalizer+"/") {
+			return
+		}
+	}
+	r.report(MissingFinalizerRule, Finding{
+		Path:       path,
+		Message:    "Application has no resources finalizer",
+		KeyVals:    []interface{}{"kind", kind, "name", name},
+		Suggestion: fmt.Sprintf("metadata:\n  finalizers:\n  - %s", ResourcesFinalizer),
+	})
+}
+
+var templateParamRegexp = regexp.MustCompile(`{{-?\s*\.?([A-Za-z0-9_.\-]+)\s*-?}}`)
+
+// returns the names of the Applications generated by the list generators of the given ApplicationSet, when all the
+// parameters of the name template can be resolved
+func generatedNames(appSet *argocdv1alpha1.ApplicationSet) ([]string, error) {
+	names := []string{}
+	for _, g := range appSet.Spec.Generators {
+		if g.List == nil {
+			continue
+		}
+		template := appSet.Spec.Template.Name
+		if g.List.Template.Name != "" {
+			template = g.List.Template.Name
+		}
+		elements, err := listParams(g.List)
+		if err != nil {
+			return nil, err
+		}
+		for _, params := range elements {
+			name, resolved := resolveTemplate(template, params)
+			if resolved {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// returns the parameters of each element of the given list generator
+func listParams(g *argocdv1alpha1.ListGenerator) ([]map[string]string, error) {
+	elements := make([]map[string]string, 0, len(g.Elements))
+	for _, e := range g.Elements {
+		element := map[string]interface{}{}
+		if err := json.Unmarshal(e, &element); err != nil {
+			return nil, err
+		}
+		params := map[string]string{}
+		flatten("", element, params)
+		elements = append(elements, params)
+	}
+	return elements, nil
+}
+
+// flattens the given element into parameters (eg: `{"values": {"env": "dev"}}` becomes `values.env=dev`)
+func flatten(prefix string, value interface{}, params map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if prefix != "" {
+				k = prefix + "." + k
+			}
+			flatten(k, val, params)
+		}
+	default:
+		params[prefix] = fmt.Sprintf("%v", v)
+	}
+}
+
+// replaces the `{{param}}` and `{{ .param }}` placeholders of the given template. Returns false if a placeholder
+// can't be resolved (eg: a Go template function)
+func resolveTemplate(template string, params map[string]string) (string, bool) {
+	resolved := true
+	result := templateParamRegexp.ReplaceAllStringFunc(template, func(placeholder string) string {
+		key := templateParamRegexp.FindStringSubmatch(placeholder)[1]
+		if v, found := params[key]; found {
+			return v
+		}
+		resolved = false
+		return placeholder
+	})
+	return result, resolved && !strings.Contains(result, "{{")
+}
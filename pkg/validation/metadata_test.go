@@ -0,0 +1,278 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplicationMetadata(t *testing.T) {
+
+	newFS := func(t *testing.T, apps map[string]string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := afs.MkdirAll("/path/to/components/cookie", 0755)
+		require.NoError(t, err)
+		for path, app := range apps {
+			err := addFile(afs, path, app)
+			require.NoError(t, err)
+		}
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, map[string]string{
+			"/path/to/apps/cookie.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+  namespace: argocd
+  finalizers:
+  - resources-finalizer.argocd.argoproj.io
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`,
+			"/path/to/apps/cookies.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: cookies
+  namespace: argocd
+spec:
+  generators:
+  - list:
+      elements:
+      - flavor: chocolate
+      - flavor: vanilla
+  template:
+    metadata:
+      name: 'cookie-{{flavor}}'
+      finalizers:
+      - resources-finalizer.argocd.argoproj.io/background
+    spec:
+      destination:
+        server: https://kubernetes.default.svc
+      source:
+        path: components/cookie`,
+		})
+		rules := validation.NewRegistry()
+		err := rules.SetEnabled("missing-finalizer", true)
+		require.NoError(t, err)
+
+		// when
+		err = validation.CheckApplications(logger, afs, validation.Options{Rules: rules}, "/path/to", "apps")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("invalid name", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, map[string]string{
+				"/path/to/apps/cookie.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: Cookie_Monster
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`,
+			})
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Errors(), 1)
+			assert.Equal(t, "name is not a valid DNS-1123 subdomain", logger.Errors()[0].Msg)
+			assert.Equal(t, []interface{}{"rule", "ACK008", "path", "/path/to/apps/cookie.yaml", "kind", "Application", "name", "Cookie_Monster"}, logger.Errors()[0].KeyVals[:8])
+		})
+
+		t.Run("duplicate names across files", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, map[string]string{
+				"/path/to/apps/dev/cookie.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`,
+				"/path/to/apps/prod/cookie.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+  namespace: argocd
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`,
+			})
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "Application name is not unique in its namespace",
+					KeyVals: []interface{}{
+						"rule", "ACK009",
+						"path", "/path/to/apps/prod/cookie.yaml",
+						"kind", "Application",
+						"name", "cookie",
+						"namespace", "argocd",
+						"duplicate", "/path/to/apps/dev/cookie.yaml",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("duplicate generated names", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, map[string]string{
+				"/path/to/apps/cookies.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: cookies
+spec:
+  goTemplate: true
+  generators:
+  - list:
+      elements:
+      - flavor: chocolate
+        cluster: dev
+      - flavor: chocolate
+        cluster: prod
+  template:
+    metadata:
+      name: 'cookie-{{ .flavor }}'
+    spec:
+      destination:
+        server: https://kubernetes.default.svc
+      source:
+        path: components/cookie`,
+			})
+
+			// when
+			err := validation.CheckApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Errors(), 1)
+			assert.Equal(t, "Application name is not unique in its namespace", logger.Errors()[0].Msg)
+			assert.Contains(t, logger.Errors()[0].KeyVals, "cookie-chocolate")
+		})
+
+		t.Run("other namespace", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, map[string]string{
+				"/path/to/apps/cookie.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+  namespace: argocd
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`,
+			})
+			opts := validation.Options{
+				ArgoCDNamespace: "gitops",
+			}
+
+			// when
+			err := validation.CheckApplications(logger, afs, opts, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "Application is not in the Argo CD namespace",
+					KeyVals: []interface{}{
+						"rule", "ACK010",
+						"path", "/path/to/apps/cookie.yaml",
+						"kind", "Application",
+						"name", "cookie",
+						"namespace", "argocd",
+						"expected", "gitops",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("missing finalizer", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, map[string]string{
+				"/path/to/apps/cookie.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`,
+			})
+			rules := validation.NewRegistry()
+			err := rules.SetEnabled("ACK011", true)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckApplications(logger, afs, validation.Options{Rules: rules}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "Application has no resources finalizer",
+					KeyVals: []interface{}{
+						"rule", "ACK011",
+						"path", "/path/to/apps/cookie.yaml",
+						"kind", "Application",
+						"name", "cookie",
+						"suggestion", "metadata:\n  finalizers:\n  - resources-finalizer.argocd.argoproj.io",
+					},
+				},
+			}, logger.Errors())
+		})
+	})
+}
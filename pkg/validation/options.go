@@ -0,0 +1,65 @@
+package validation
+
+import "path/filepath"
+
+// Options holds the settings which apply to all the checks
+type Options struct {
+	// Rules are the rules to check, along with their settings. The built-in rules apply if nil
+	Rules *Registry
+	// Strict turns all warnings into errors
+	Strict bool
+	// RemoteResources defines how the remote resources of the kustomizations are handled (`fail` if empty)
+	RemoteResources RemoteResourcesMode
+	// VendoredResources are the remote resources vendored in local directories, used in the `vendor` mode
+	VendoredResources []RemoteResource
+	// BuildOptions are the `kustomize build` flags, as configured in Argo CD (eg: `--enable-helm`)
+	BuildOptions []string
+	// EnableHelm enables the inflation of the `helmCharts` of the kustomizations (same as the `--enable-helm` build option)
+	EnableHelm bool
+	// HelmChartHome is the local directory of the Helm charts, which overrides the `helmGlobals.chartHome` of the kustomizations
+	HelmChartHome string
+	// Paths restricts the checks to the given kustomization directories and Application files (eg: the paths
+	// affected by some changes). All paths are checked if nil.
+	Paths map[string]bool
+	// Exclude are the patterns of the paths which are not checked, relative to the base directory
+	// (eg: `components/*/experimental`). The contents of an excluded directory are not checked either.
+	Exclude []string
+	// KubeVersion is the Kubernetes version of the target clusters (eg: `1.27`)
+	KubeVersion string
+	// ArgoCDNamespace is the namespace of the Applications and ApplicationSets (`argocd` if empty)
+	ArgoCDNamespace string
+	// Clusters are the destination clusters managed by Argo CD. The destinations of the Applications are not
+	// verified if nil.
+	Clusters []Cluster
+	// StubSOPS replaces the KSOPS generators of the kustomizations with placeholder Secrets, so that the kustomizations
+	// can be built without the SOPS decryption keys
+	StubSOPS bool
+	// AllowedRegistries are the registries of the container images, which may include a path (eg: `quay.io/org`).
+	// The registries of the images are not verified if empty.
+	AllowedRegistries []string
+	// ExternalObjects are the objects referenced by the rendered objects, which are not rendered along with them
+	// (eg: Secrets created by an operator)
+	ExternalObjects []ObjectReference
+
+	// sources are the source directories of the Applications, recorded during a run of a Checker
+	sources *clusterSources
+}
+
+// returns true if the given kustomization directory or Application file must be checked
+func (o Options) selected(path string) bool {
+	return o.Paths == nil || o.Paths[path]
+}
+
+// returns true if the given path matches one of the exclusion patterns
+func (o Options) excluded(baseDir, path string) bool {
+	rel, err := filepath.Rel(baseDir, path)
+	if err != nil {
+		return false
+	}
+	for _, p := range o.Exclude {
+		if matched, _ := filepath.Match(p, rel); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package validation
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+
+	"github.com/google/cel-go/cel"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// Policy is a house rule written as a CEL expression, which is evaluated against the Applications and
+// ApplicationSets, and against the objects rendered by `kustomize build`.
+// For example:
+//
+//	policies:
+//	- name: application-owner
+//	  description: Applications must have an owner
+//	  kinds:
+//	  - Application
+//	  expression: has(object.spec.info) && object.spec.info.exists(i, i.name == 'owner')
+type Policy struct {
+	// ID of the policy, used to configure or ignore the policy like other rules (defaults to the name of the policy)
+	ID string `json:"id,omitempty"`
+	// Name of the policy
+	Name string `json:"name"`
+	// Description of the policy
+	Description string `json:"description,omitempty"`
+	// Kinds of the objects to which the policy applies. The policy applies to all objects if empty.
+	Kinds []string `json:"kinds,omitempty"`
+	// Expression is the CEL expression which evaluates to `true` when the object (available as `object`) complies with the policy
+	Expression string `json:"expression"`
+	// Severity of the violations (defaults to `error`)
+	Severity Severity `json:"severity,omitempty"`
+
+	program cel.Program
+}
+
+type policyFile struct {
+	Policies []Policy `json:"policies"`
+}
+
+// LoadPolicies loads and compiles the CEL policies declared in the YAML files of the given directory. Returns an error
+// if the directory contains Rego policies (`.rego` files), which are not supported.
+func LoadPolicies(logger Logger, afs afero.Afero, dir string) ([]Policy, error) {
+	env, err := cel.NewEnv(cel.Variable("object", cel.DynType))
+	if err != nil {
+		return nil, err
+	}
+	policies := []Policy{}
+	if err := afs.Walk(dir, func(path string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".rego" {
+			return fmt.Errorf("unsupported policy file %s: only CEL policies are supported, not Rego", path)
+		}
+		if info.IsDir() || !(filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml") {
+			return nil
+		}
+		logger.Debug("loading policies", "path", path)
+		data, err := afs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f := policyFile{}
+		if err := k8syaml.UnmarshalStrict(data, &f); err != nil {
+			return fmt.Errorf("invalid policy file %s: %w", path, err)
+		}
+		for _, p := range f.Policies {
+			if p.Name == "" {
+				return fmt.Errorf("invalid policy file %s: missing policy name", path)
+			}
+			if p.ID == "" {
+				p.ID = p.Name
+			}
+			if p.Severity == "" {
+				p.Severity = SeverityError
+			}
+			ast, issues := env.Compile(p.Expression)
+			if issues != nil && issues.Err() != nil {
+				return fmt.Errorf("invalid policy '%s' in %s: %w", p.Name, path, issues.Err())
+			}
+			if p.program, err = env.Program(ast); err != nil {
+				return fmt.Errorf("invalid policy '%s' in %s: %w", p.Name, path, err)
+			}
+			policies = append(policies, p)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// Rule returns the policy as a rule, to register it along with the built-in rules
+func (p Policy) Rule() Rule {
+	return policyRule{
+		Policy: p,
+	}
+}
+
+type policyRule struct {
+	Policy
+}
+
+var _ ResourcesRule = policyRule{}
+var _ ApplicationsRule = policyRule{}
+
+func (r policyRule) ID() string {
+	return r.Policy.ID
+}
+
+func (r policyRule) Name() string {
+	return r.Policy.Name
+}
+
+func (r policyRule) Description() string {
+	return r.Policy.Description
+}
+
+func (r policyRule) DefaultSeverity() Severity {
+	return r.Policy.Severity
+}
+
+func (r policyRule) EnabledByDefault() bool {
+	return true
+}
+
+func (r policyRule) CheckResources(path string, objs []*yaml.RNode) ([]Finding, error) {
+	return r.check(path, objs)
+}
+
+func (r policyRule) CheckApplications(path string, objs []*yaml.RNode) ([]Finding, error) {
+	return r.check(path, objs)
+}
+
+func (r policyRule) appliesTo(obj *yaml.RNode) bool {
+	if len(r.Kinds) == 0 {
+		return true
+	}
+	for _, k := range r.Kinds {
+		if k == obj.GetKind() {
+			return true
+		}
+	}
+	return false
+}
+
+// verifies that the given objects comply with the policy
+func (r policyRule) check(path string, objs []*yaml.RNode) ([]Finding, error) {
+	findings := []Finding{}
+	for _, obj := range objs {
+		if !r.appliesTo(obj) {
+			continue
+		}
+		data, err := obj.Map()
+		if err != nil {
+			return nil, err
+		}
+		out, _, err := r.program.Eval(map[string]interface{}{
+			"object": data,
+		})
+		switch {
+		case err != nil:
+			findings = append(findings, Finding{
+				Path:    path,
+				Message: "policy violation",
+				KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "err", err},
+			})
+		case out.Value() != true:
+			findings = append(findings, Finding{
+				Path:    path,
+				Message: "policy violation",
+				KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName()},
+			})
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,306 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPolicies(t *testing.T) {
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/policies/policies.yaml", `policies:
+- name: application-owner
+  kinds:
+  - Application
+  expression: has(object.spec.info) && object.spec.info.exists(i, i.name == 'owner')
+- name: no-latest-tag
+  expression: "!has(object.spec) || !has(object.spec.image) || !object.spec.image.endsWith(':latest')"`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/policies/README.md", `not a policy file`)
+		require.NoError(t, err)
+
+		// when
+		policies, err := validation.LoadPolicies(logger, afs, "/path/to/policies")
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, policies, 2)
+		assert.Equal(t, "application-owner", policies[0].Name)
+		assert.Equal(t, []string{"Application"}, policies[0].Kinds)
+		assert.Equal(t, "no-latest-tag", policies[1].Name)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("invalid expression", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/policies/policies.yaml", `policies:
+- name: invalid
+  expression: object.spec.(`)
+			require.NoError(t, err)
+
+			// when
+			_, err = validation.LoadPolicies(logger, afs, "/path/to/policies")
+
+			// then
+			require.ErrorContains(t, err, "invalid policy 'invalid' in /path/to/policies/policies.yaml")
+		})
+
+		t.Run("missing name", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/policies/policies.yaml", `policies:
+- expression: "true"`)
+			require.NoError(t, err)
+
+			// when
+			_, err = validation.LoadPolicies(logger, afs, "/path/to/policies")
+
+			// then
+			require.EqualError(t, err, "invalid policy file /path/to/policies/policies.yaml: missing policy name")
+		})
+
+		t.Run("rego policy", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/policies/owner.rego", `package argocd
+
+deny[msg] {
+	not input.spec.info
+	msg := "missing owner"
+}`)
+			require.NoError(t, err)
+
+			// when
+			_, err = validation.LoadPolicies(logger, afs, "/path/to/policies")
+
+			// then
+			require.EqualError(t, err, "unsupported policy file /path/to/policies/owner.rego: only CEL policies are supported, not Rego")
+		})
+	})
+}
+
+func TestCheckPolicies(t *testing.T) {
+
+	newRules := func(t *testing.T, logger validation.Logger) *validation.Registry {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/policies/policies.yaml", `policies:
+- name: application-owner
+  kinds:
+  - Application
+  expression: has(object.spec.info) && object.spec.info.exists(i, i.name == 'owner')
+- name: configmap-team-label
+  kinds:
+  - ConfigMap
+  expression: has(object.metadata.labels) && 'team' in object.metadata.labels`)
+		require.NoError(t, err)
+		policies, err := validation.LoadPolicies(logger, afs, "/policies")
+		require.NoError(t, err)
+		rules := validation.NewRegistry()
+		for _, p := range policies {
+			err := rules.Register(p.Rule())
+			require.NoError(t, err)
+		}
+		return rules
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := afs.MkdirAll("/path/to/components/cookie", 0755)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  info:
+  - name: owner
+    value: cookie-monster
+  source:
+    path: components/cookie`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+  labels:
+    team: monsters
+data:
+  cookie: yummy`)
+		require.NoError(t, err)
+		opts := validation.Options{
+			Rules: newRules(t, logger),
+		}
+
+		// when
+		err = validation.CheckApplications(logger, afs, opts, "/path/to", "apps")
+		require.NoError(t, err)
+		err = validation.CheckComponents(logger, afs, opts, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("application violation", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := afs.MkdirAll("/path/to/components/cookie", 0755)
+			require.NoError(t, err)
+			err = addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`)
+			require.NoError(t, err)
+			opts := validation.Options{
+				Rules: newRules(t, logger),
+			}
+
+			// when
+			err = validation.CheckApplications(logger, afs, opts, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Contains(t, logger.Errors(), LogRecord{
+				Msg: "policy violation",
+				KeyVals: []interface{}{
+					"rule", "application-owner",
+					"path", "/path/to/apps/cookie.yaml",
+					"kind", "Application",
+					"name", "cookie",
+				},
+			})
+		})
+
+		t.Run("application violation with warning severity", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := afs.MkdirAll("/path/to/components/cookie", 0755)
+			require.NoError(t, err)
+			err = addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`)
+			require.NoError(t, err)
+			rules := newRules(t, logger)
+			err = rules.SetSeverity("application-owner", validation.SeverityWarning)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckApplications(logger, afs, validation.Options{Rules: rules}, "/path/to", "apps")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Len(t, logger.Warnings(), 1)
+		})
+
+		t.Run("rendered object violation", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/components/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+			require.NoError(t, err)
+			err = addFile(afs, "/path/to/components/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+data:
+  cookie: yummy`)
+			require.NoError(t, err)
+			opts := validation.Options{
+				Rules: newRules(t, logger),
+			}
+
+			// when
+			err = validation.CheckComponents(logger, afs, opts, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Contains(t, logger.Errors(), LogRecord{
+				Msg: "policy violation",
+				KeyVals: []interface{}{
+					"rule", "configmap-team-label",
+					"path", "/path/to/components",
+					"kind", "ConfigMap",
+					"name", "cookie",
+				},
+			})
+		})
+	})
+}
@@ -0,0 +1,118 @@
+package validation
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/types"
+)
+
+// reference is a local file or directory referenced in a field of a kustomization
+type reference struct {
+	// field is the field of the kustomization in which the reference is declared (eg: `patches.path`)
+	field string
+	// path is the reference, relative to the kustomization directory
+	path string
+	// optional is true if the file or directory may not exist (eg: the Helm chart home, where charts are downloaded)
+	optional bool
+}
+
+// returns the local files and directories referenced in the given kustomization
+func kustomizationReferences(kobj types.Kustomization) []reference {
+	refs := []reference{}
+	add := func(field string, paths ...string) {
+		for _, p := range paths {
+			// skip inline contents (eg: inline patches or generator configurations)
+			if p != "" && !strings.Contains(p, "\n") && !isRemoteResource(p) {
+				refs = append(refs, reference{
+					field: field,
+					path:  p,
+				})
+			}
+		}
+	}
+	add("resources", kobj.Resources...)
+	add("bases", kobj.Bases...) //nolint:staticcheck
+	add("components", kobj.Components...)
+	add("crds", kobj.Crds...)
+	add("configurations", kobj.Configurations...)
+	add("generators", kobj.Generators...)
+	add("transformers", kobj.Transformers...)
+	add("validators", kobj.Validators...)
+	add("openapi.path", kobj.OpenAPI["path"])
+	for _, p := range kobj.PatchesStrategicMerge { //nolint:staticcheck
+		add("patchesStrategicMerge", string(p))
+	}
+	for _, p := range kobj.PatchesJson6902 { //nolint:staticcheck
+		add("patchesJson6902.path", p.Path)
+	}
+	for _, p := range kobj.Patches {
+		add("patches.path", p.Path)
+	}
+	for _, g := range kobj.ConfigMapGenerator {
+		add("configMapGenerator.files", fileSourcePaths(g.FileSources)...)
+		add("configMapGenerator.envs", append(g.EnvSources, g.EnvSource)...)
+	}
+	for _, g := range kobj.SecretGenerator {
+		add("secretGenerator.files", fileSourcePaths(g.FileSources)...)
+		add("secretGenerator.envs", append(g.EnvSources, g.EnvSource)...)
+	}
+	for _, r := range kobj.Replacements {
+		add("replacements.path", r.Path)
+	}
+	for _, c := range kobj.HelmCharts {
+		add("helmCharts.valuesFile", c.ValuesFile)
+		add("helmCharts.additionalValuesFiles", c.AdditionalValuesFiles...)
+	}
+	for _, c := range kobj.HelmChartInflationGenerator { //nolint:staticcheck
+		add("helmChartInflationGenerator.values", c.Values)
+	}
+	if len(kobj.HelmCharts) > 0 || len(kobj.HelmChartInflationGenerator) > 0 { //nolint:staticcheck
+		chartHome := "charts"
+		if kobj.HelmGlobals != nil && kobj.HelmGlobals.ChartHome != "" {
+			chartHome = kobj.HelmGlobals.ChartHome
+		}
+		refs = append(refs, reference{
+			field:    "helmGlobals.chartHome",
+			path:     chartHome,
+			optional: true,
+		})
+	}
+	return refs
+}
+
+// returns the paths of the given file sources (`[{key}=]{path}`)
+func fileSourcePaths(sources []string) []string {
+	paths := make([]string, len(sources))
+	for i, f := range sources {
+		if j := strings.LastIndex(f, "="); j > 0 {
+			paths[i] = f[j+1:]
+		} else {
+			paths[i] = f
+		}
+	}
+	return paths
+}
+
+// verifies that all the local files and directories referenced in the kustomization exist
+func checkKustomizeReferences(logger Logger, r *reporter, afs afero.Afero, path string, kobj types.Kustomization) error {
+	logger.Debug("checking kustomization references", "path", path)
+	for _, ref := range append(kustomizationReferences(kobj), ksopsReferences(afs, filepath.Dir(path), kobj)...) {
+		if ref.optional {
+			continue
+		}
+		exists, err := afs.Exists(filepath.Join(filepath.Dir(path), ref.path))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			r.report(MissingReferenceRule, Finding{
+				Path:    path,
+				Message: "referenced file or directory does not exist",
+				KeyVals: []interface{}{"field", ref.field, "reference", ref.path},
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckKustomizeReferences(t *testing.T) {
+
+	t.Run("success", func(t *testing.T) {
+
+		t.Run("existing and remote references", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/components/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml
+- https://github.com/org/repo//path?ref=v1
+configMapGenerator:
+- name: cm
+  files:
+  - config=config.properties
+  envs:
+  - config.env`)
+			require.NoError(t, err)
+			err = addFile(afs, "/path/to/components/base/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm-1
+data:
+  cookie: yummy`)
+			require.NoError(t, err)
+			err = addFile(afs, "/path/to/components/base/config.properties", `cookie=yummy`)
+			require.NoError(t, err)
+			err = addFile(afs, "/path/to/components/base/config.env", `PASTA=yummy`)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{
+				RemoteResources: validation.RemoteResourcesSkip,
+			}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("missing references", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := afero.Afero{
+				Fs: afero.NewMemMapFs(),
+			}
+			err := addFile(afs, "/path/to/components/base/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- ../missing
+components:
+- ../../missing-component
+patches:
+- path: missing-patch.yaml
+replacements:
+- path: missing-replacement.yaml
+configMapGenerator:
+- name: cm
+  files:
+  - config=missing.properties
+secretGenerator:
+- name: secret
+  envs:
+  - missing.env`)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 6 error(s)")
+			for field, ref := range map[string]string{
+				"resources":                "../missing",
+				"components":               "../../missing-component",
+				"patches.path":             "missing-patch.yaml",
+				"replacements.path":        "missing-replacement.yaml",
+				"configMapGenerator.files": "missing.properties",
+				"secretGenerator.envs":     "missing.env",
+			} {
+				assert.Contains(t, logger.Errors(), LogRecord{
+					Msg: "referenced file or directory does not exist",
+					KeyVals: []interface{}{
+						"rule", "ACK004",
+						"path", "/path/to/components/base/kustomization.yaml",
+						"field", field,
+						"reference", ref,
+					},
+				})
+			}
+		})
+	})
+}
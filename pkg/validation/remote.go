@@ -0,0 +1,265 @@
+package validation
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/types"
+	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// RemoteResourcesMode defines how the remote resources of the kustomizations (eg:
+// `github.com/org/repo//path?ref=v1`) are handled, since they can't be fetched by the checker
+type RemoteResourcesMode string
+
+const (
+	// RemoteResourcesFail reports the remote resources as errors
+	RemoteResourcesFail RemoteResourcesMode = "fail"
+	// RemoteResourcesSkip ignores the remote resources when running `kustomize build`
+	RemoteResourcesSkip RemoteResourcesMode = "skip"
+	// RemoteResourcesVendor replaces the remote resources with the local directories declared in the lockfile
+	RemoteResourcesVendor RemoteResourcesMode = "vendor"
+)
+
+// LockFile is the default name of the lockfile of the vendored remote resources, at the root of the repository
+const LockFile = ".argocd-checker.lock.yaml"
+
+// RemoteResource is a remote resource vendored in a local directory
+type RemoteResource struct {
+	// URL of the remote resource, as written in the kustomizations
+	URL string `json:"url"`
+	// Path of the local directory in which the resource is vendored, relative to the lockfile
+	Path string `json:"path"`
+}
+
+type lockFile struct {
+	Remotes []RemoteResource `json:"remotes"`
+}
+
+// LoadLockFile reads the vendored remote resources declared in the lockfile at the given path.
+// The paths of the returned resources are relative to the current directory.
+func LoadLockFile(afs afero.Afero, path string) ([]RemoteResource, error) {
+	data, err := afs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := lockFile{}
+	if err := k8syaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid lockfile %s: %w", path, err)
+	}
+	for i, r := range f.Remotes {
+		if r.URL == "" || r.Path == "" {
+			return nil, fmt.Errorf("invalid lockfile %s: missing url or path", path)
+		}
+		f.Remotes[i].Path = filepath.Join(filepath.Dir(path), r.Path)
+	}
+	return f.Remotes, nil
+}
+
+// returns true if the given resource is a remote URL
+// (eg: `https://github.com/org/repo//path?ref=v1` or `github.com/org/repo//path?ref=v1`)
+func isRemoteResource(path string) bool {
+	if strings.Contains(path, "://") || strings.HasPrefix(path, "git@") || strings.Contains(path, "?ref=") {
+		return true
+	}
+	for _, host := range []string{"github.com/", "gitlab.com/", "bitbucket.org/"} {
+		if strings.HasPrefix(path, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the remote resources of the given kustomization
+func remoteResources(kobj types.Kustomization) []string {
+	remotes := []string{}
+	for _, resources := range [][]string{kobj.Resources, kobj.Bases, kobj.Components} { //nolint:staticcheck
+		for _, r := range resources {
+			if isRemoteResource(r) {
+				remotes = append(remotes, r)
+			}
+		}
+	}
+	return remotes
+}
+
+var pinnedRefRegexp = regexp.MustCompile(`^(v?[0-9]+(\.[0-9]+)*([-+].+)?|[0-9a-f]{7,40})$`)
+
+// returns the `ref` (or `version`) of the given remote resource, and true if it is a tag or a commit SHA
+// (as opposed to a branch)
+func remoteRef(resource string) (string, bool) {
+	i := strings.Index(resource, "?")
+	if i < 0 {
+		return "", false
+	}
+	query, err := url.ParseQuery(resource[i+1:])
+	if err != nil {
+		return "", false
+	}
+	ref := query.Get("ref")
+	if ref == "" {
+		ref = query.Get("version")
+	}
+	return ref, pinnedRefRegexp.MatchString(ref)
+}
+
+// verifies that the remote resources of the kustomization are pinned to a tag or a commit, and
+// that they can be resolved with the given mode
+func checkRemoteResources(logger Logger, r *reporter, afs afero.Afero, opts Options, path string, kobj types.Kustomization) error {
+	for _, res := range remoteResources(kobj) {
+		logger.Debug("checking remote resource", "path", path, "resource", res)
+		if ref, pinned := remoteRef(res); !pinned {
+			r.report(FloatingRemoteRefRule, Finding{
+				Path:       path,
+				Message:    "remote resource is not pinned to a tag or a commit",
+				KeyVals:    []interface{}{"resource", res, "ref", ref},
+				Suggestion: "use a tag or a commit SHA in the `ref` query parameter",
+			})
+		}
+		switch opts.RemoteResources {
+		case RemoteResourcesSkip:
+			logger.Debug("skipping remote resource", "path", path, "resource", res)
+		case RemoteResourcesVendor:
+			vendored, found := lookupVendoredResource(opts.VendoredResources, res)
+			if !found {
+				r.report(RemoteResourceRule, Finding{
+					Path:    path,
+					Message: "remote resource is not vendored",
+					KeyVals: []interface{}{"resource", res},
+				})
+				continue
+			}
+			if exists, err := afs.DirExists(vendored.Path); err != nil {
+				return err
+			} else if !exists {
+				r.report(RemoteResourceRule, Finding{
+					Path:    path,
+					Message: "vendored directory of remote resource does not exist",
+					KeyVals: []interface{}{"resource", res, "vendored", vendored.Path},
+				})
+			}
+		default:
+			r.report(RemoteResourceRule, Finding{
+				Path:       path,
+				Message:    "remote resource can't be fetched",
+				KeyVals:    []interface{}{"resource", res},
+				Suggestion: "vendor the resource and use the 'vendor' mode, or use the 'skip' mode",
+			})
+		}
+	}
+	return nil
+}
+
+func lookupVendoredResource(vendored []RemoteResource, resource string) (RemoteResource, bool) {
+	for _, v := range vendored {
+		if v.URL == resource {
+			return v, true
+		}
+	}
+	return RemoteResource{}, false
+}
+
+// rewrites the kustomizations of the in-memory filesystem so that `kustomize build` does not try to fetch remote
+// resources: remote resources are replaced with their vendored directory (which is added in the in-memory filesystem),
+// or removed.
+func resolveRemoteResources(logger Logger, afs afero.Afero, fsys kfsys.FileSystem, opts Options, baseDir string) error {
+	kustomizations, err := lookupKustomizationFiles(fsys, baseDir)
+	if err != nil {
+		return err
+	}
+	visited := map[string]bool{}
+	for len(kustomizations) > 0 {
+		path := kustomizations[0]
+		kustomizations = kustomizations[1:]
+		if visited[path] {
+			continue
+		}
+		visited[path] = true
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var kobj types.Kustomization
+		if err := kobj.Unmarshal(data); err != nil {
+			// reported when checking the kustomization
+			continue
+		}
+		if len(remoteResources(kobj)) == 0 {
+			continue
+		}
+		resolve := func(resources []string) ([]string, error) {
+			result := make([]string, 0, len(resources))
+			for _, res := range resources {
+				if !isRemoteResource(res) {
+					result = append(result, res)
+					continue
+				}
+				vendored, found := lookupVendoredResource(opts.VendoredResources, res)
+				if found {
+					if found, err = afs.DirExists(vendored.Path); err != nil {
+						return nil, err
+					}
+				}
+				if opts.RemoteResources != RemoteResourcesVendor || !found {
+					logger.Debug("removing remote resource", "path", path, "resource", res)
+					continue
+				}
+				if !fsys.Exists(vendored.Path) {
+					if err := addToFS(logger, afs, fsys, vendored.Path); err != nil {
+						return nil, err
+					}
+					// the vendored resources may also have remote resources
+					vendoredKustomizations, err := lookupKustomizationFiles(fsys, vendored.Path)
+					if err != nil {
+						return nil, err
+					}
+					kustomizations = append(kustomizations, vendoredKustomizations...)
+				}
+				rel, err := filepath.Rel(filepath.Dir(path), vendored.Path)
+				if err != nil {
+					return nil, err
+				}
+				logger.Debug("replacing remote resource", "path", path, "resource", res, "vendored", rel)
+				result = append(result, rel)
+			}
+			return result, nil
+		}
+		if kobj.Resources, err = resolve(kobj.Resources); err != nil {
+			return err
+		}
+		if kobj.Bases, err = resolve(kobj.Bases); err != nil { //nolint:staticcheck
+			return err
+		}
+		if kobj.Components, err = resolve(kobj.Components); err != nil {
+			return err
+		}
+		if data, err = k8syaml.Marshal(kobj); err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the paths of the kustomization files in the given directory and its subdirectories
+func lookupKustomizationFiles(fsys kfsys.FileSystem, dir string) ([]string, error) {
+	paths := []string{}
+	err := fsys.Walk(dir, func(path string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && isKustomizationFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
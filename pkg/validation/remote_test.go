@@ -0,0 +1,240 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteResources(t *testing.T) {
+
+	newFS := func(t *testing.T, remote string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml
+- `+remote)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+data:
+  cookie: yummy`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/vendor/pasta/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/vendor/pasta/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: pasta
+data:
+  pasta: yummy`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/.argocd-checker.lock.yaml", `remotes:
+- url: https://github.com/org/repo//pasta?ref=v1.0.0
+  path: vendor/pasta`)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("fail mode", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, "https://github.com/org/repo//pasta?ref=v1.0.0")
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+		// then
+		require.EqualError(t, err, "found 1 error(s)")
+		assert.Equal(t, []LogRecord{
+			{
+				Msg: "remote resource can't be fetched",
+				KeyVals: []interface{}{
+					"rule", "ACK005",
+					"path", "/path/to/components/cookie/kustomization.yaml",
+					"resource", "https://github.com/org/repo//pasta?ref=v1.0.0",
+					"suggestion", "vendor the resource and use the 'vendor' mode, or use the 'skip' mode",
+				},
+			},
+		}, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("skip mode", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, "https://github.com/org/repo//pasta?ref=v1.0.0")
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{
+			RemoteResources: validation.RemoteResourcesSkip,
+		}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("vendor mode", func(t *testing.T) {
+
+		t.Run("vendored", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, "https://github.com/org/repo//pasta?ref=v1.0.0")
+			vendored, err := validation.LoadLockFile(afs, "/path/to/.argocd-checker.lock.yaml")
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{
+				RemoteResources:   validation.RemoteResourcesVendor,
+				VendoredResources: vendored,
+			}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+
+		t.Run("not vendored", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, "https://github.com/org/repo//pasta?ref=v2.0.0")
+			vendored, err := validation.LoadLockFile(afs, "/path/to/.argocd-checker.lock.yaml")
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{
+				RemoteResources:   validation.RemoteResourcesVendor,
+				VendoredResources: vendored,
+			}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "remote resource is not vendored",
+					KeyVals: []interface{}{
+						"rule", "ACK005",
+						"path", "/path/to/components/cookie/kustomization.yaml",
+						"resource", "https://github.com/org/repo//pasta?ref=v2.0.0",
+					},
+				},
+			}, logger.Errors())
+		})
+	})
+
+	t.Run("floating refs", func(t *testing.T) {
+		for _, remote := range []string{
+			"https://github.com/org/repo//pasta",
+			"https://github.com/org/repo//pasta?ref=main",
+			"github.com/org/repo//pasta?ref=feature/v1",
+		} {
+			t.Run(remote, func(t *testing.T) {
+				// given
+				logger := NewTestLogger(os.Stdout, charmlog.Options{
+					Level: charmlog.InfoLevel,
+				})
+				afs := newFS(t, remote)
+
+				// when
+				err := validation.CheckComponents(logger, afs, validation.Options{
+					RemoteResources: validation.RemoteResourcesSkip,
+				}, "/path/to", "components")
+
+				// then
+				require.NoError(t, err)
+				require.Len(t, logger.Warnings(), 1)
+				assert.Equal(t, "remote resource is not pinned to a tag or a commit", logger.Warnings()[0].Msg)
+			})
+		}
+
+		for _, remote := range []string{
+			"https://github.com/org/repo//pasta?ref=v1.0.0",
+			"https://github.com/org/repo//pasta?ref=1.2",
+			"https://github.com/org/repo//pasta?ref=8c9e1b2f",
+		} {
+			t.Run(remote, func(t *testing.T) {
+				// given
+				logger := NewTestLogger(os.Stdout, charmlog.Options{
+					Level: charmlog.InfoLevel,
+				})
+				afs := newFS(t, remote)
+
+				// when
+				err := validation.CheckComponents(logger, afs, validation.Options{
+					RemoteResources: validation.RemoteResourcesSkip,
+				}, "/path/to", "components")
+
+				// then
+				require.NoError(t, err)
+				assert.Empty(t, logger.Warnings())
+			})
+		}
+	})
+}
+
+func TestLoadLockFile(t *testing.T) {
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/.argocd-checker.lock.yaml", `remotes:
+- url: https://github.com/org/repo//pasta?ref=v1.0.0
+  path: vendor/pasta`)
+		require.NoError(t, err)
+
+		// when
+		vendored, err := validation.LoadLockFile(afs, "/path/to/.argocd-checker.lock.yaml")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []validation.RemoteResource{
+			{
+				URL:  "https://github.com/org/repo//pasta?ref=v1.0.0",
+				Path: "/path/to/vendor/pasta",
+			},
+		}, vendored)
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		// given
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/.argocd-checker.lock.yaml", `remotes:
+- url: https://github.com/org/repo//pasta?ref=v1.0.0`)
+		require.NoError(t, err)
+
+		// when
+		_, err = validation.LoadLockFile(afs, "/path/to/.argocd-checker.lock.yaml")
+
+		// then
+		require.EqualError(t, err, "invalid lockfile /path/to/.argocd-checker.lock.yaml: missing url or path")
+	})
+}
@@ -0,0 +1,194 @@
+package validation
+
+import (
+	"fmt"
+	iofs "io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	kvalidation "k8s.io/apimachinery/pkg/util/validation"
+	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// RenderedApplication holds the objects rendered from the sources of an Application
+type RenderedApplication struct {
+	// Name of the Application
+	Name string
+	// Path of the file in which the Application is declared
+	Path string
+	// Objects rendered from the sources of the Application
+	Objects []*yaml.RNode
+}
+
+// RenderApplications builds the sources of the Applications found in the given paths, as Argo CD would: with
+// `kustomize build` when the source path contains a kustomization file, or by reading the YAML files of the source
+// path otherwise. ApplicationSets are skipped, since their Applications are generated by Argo CD.
+func RenderApplications(logger Logger, afs afero.Afero, opts Options, baseDir string, apps ...string) ([]RenderedApplication, error) {
+	fsys, err := NewInMemoryFS(logger, afs, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveRemoteResources(logger, afs, fsys, opts, baseDir); err != nil {
+		return nil, err
+	}
+	if err := resolveHelmCharts(logger, afs, fsys, opts, baseDir); err != nil {
+		return nil, err
+	}
+	if err := resolveSOPS(logger, fsys, opts, baseDir); err != nil {
+		return nil, err
+	}
+	rendered := []RenderedApplication{}
+	for _, path := range apps {
+		p := filepath.Join(baseDir, path)
+		logger.Info("👀 rendering Applications", "path", p)
+		if err := afs.Walk(p, func(path string, info iofs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if opts.excluded(baseDir, path) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || filepath.Ext(info.Name()) != ".yaml" {
+				return nil
+			}
+			data, err := afs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			objs, err := readObjects(data)
+			if err != nil {
+				logger.Debug("skipping invalid YAML file", "path", path, "err", err)
+				return nil
+			}
+			for _, obj := range objs {
+				sources, err := applicationSources(obj)
+				if err != nil {
+					return fmt.Errorf("invalid %s in %s: %w", obj.GetKind(), path, err)
+				}
+				if sources == nil {
+					continue
+				}
+				if obj.GetKind() != "Application" {
+					logger.Debug("skipping ApplicationSet", "path", path, "name", obj.GetName())
+					continue
+				}
+				app := RenderedApplication{
+					Name:    obj.GetName(),
+					Path:    path,
+					Objects: []*yaml.RNode{},
+				}
+				for _, s := range sources {
+					if s.Path == "" {
+						logger.Debug("skipping source without path", "path", path, "name", app.Name, "repoURL", s.RepoURL)
+						continue
+					}
+					objs, err := renderSource(logger, afs, fsys, opts, filepath.Join(baseDir, s.Path))
+					if err != nil {
+						return fmt.Errorf("failed to render Application '%s' in %s: %w", app.Name, path, err)
+					}
+					app.Objects = append(app.Objects, objs...)
+				}
+				rendered = append(rendered, app)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return rendered, nil
+}
+
+// returns the objects of the given source directory: the output of `kustomize build` if the directory
+// contains a kustomization file, or the objects of its YAML files otherwise
+func renderSource(logger Logger, afs afero.Afero, fsys kfsys.FileSystem, opts Options, dir string) ([]*yaml.RNode, error) {
+	if _, found := lookupKustomizationFile(logger, afs, dir); found {
+		return checkBuild(logger, fsys, opts, dir)
+	}
+	files, err := afs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	objs := []*yaml.RNode{}
+	for _, f := range files {
+		if f.IsDir() || !(filepath.Ext(f.Name()) == ".yaml" || filepath.Ext(f.Name()) == ".yml") {
+			continue
+		}
+		data, err := afs.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		o, err := readObjects(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML file %s: %w", filepath.Join(dir, f.Name()), err)
+		}
+		objs = append(objs, o...)
+	}
+	return objs, nil
+}
+
+// WriteRenderedApplications writes the rendered objects of each Application in `<outDir>/<app-name>/<kind>-<name>.yaml`.
+// Existing files in the directory of each Application are removed first, so that deleted objects don't linger.
+// Objects with the same kind and name (eg: in different namespaces) are written in the same file.
+// Nothing is written if the name of an Application is not a valid DNS-1123 subdomain, or is not unique.
+func WriteRenderedApplications(logger Logger, afs afero.Afero, outDir string, apps []RenderedApplication) error {
+	paths := map[string]string{}
+	for _, app := range apps {
+		if errs := kvalidation.IsDNS1123Subdomain(app.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid name of the Application in %s: '%s' is not a valid DNS-1123 subdomain", app.Path, app.Name)
+		}
+		if path, found := paths[app.Name]; found {
+			return fmt.Errorf("duplicate Application name '%s' in %s and %s", app.Name, path, app.Path)
+		}
+		paths[app.Name] = app.Path
+	}
+	for _, app := range apps {
+		dir := filepath.Join(outDir, app.Name)
+		if !inDir(outDir, dir) {
+			return fmt.Errorf("output directory of the Application '%s' is not in %s", app.Name, outDir)
+		}
+		if err := afs.RemoveAll(dir); err != nil {
+			return err
+		}
+		if err := afs.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		files := map[string][]string{}
+		for _, obj := range app.Objects {
+			s, err := obj.String()
+			if err != nil {
+				return err
+			}
+			name := strings.ToLower(fmt.Sprintf("%s-%s.yaml", obj.GetKind(), obj.GetName()))
+			files[name] = append(files[name], s)
+		}
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if !inDir(dir, path) {
+				return fmt.Errorf("output file of the objects '%s' is not in %s", name, dir)
+			}
+			logger.Debug("writing rendered objects", "path", path)
+			if err := afs.WriteFile(path, []byte(strings.Join(files[name], "---\n")), 0644); err != nil {
+				return err
+			}
+		}
+		logger.Info("📝 rendered Application", "name", app.Name, "path", dir, "objects", len(app.Objects))
+	}
+	return nil
+}
+
+// returns true if the given path is a strict subpath of the given directory
+func inDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,191 @@
+package validation_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestRenderApplications(t *testing.T) {
+
+	newFS := func(t *testing.T) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  sources:
+  - path: components/cookie
+  - path: components/pasta
+---
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: cookies
+spec:
+  template:
+    spec:
+      source:
+        path: components/cookie`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+namespace: cookie
+resources:
+- configmap.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+data:
+  cookie: yummy`)
+		require.NoError(t, err)
+		// plain manifests, without kustomization
+		err = addFile(afs, "/path/to/components/pasta/namespace.yaml", `apiVersion: v1
+kind: Namespace
+metadata:
+  name: pasta`)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t)
+
+		// when
+		rendered, err := validation.RenderApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, rendered, 1)
+		assert.Equal(t, "cookie", rendered[0].Name)
+		assert.Equal(t, "/path/to/apps/cookie.yaml", rendered[0].Path)
+		require.Len(t, rendered[0].Objects, 2)
+		assert.Equal(t, "ConfigMap", rendered[0].Objects[0].GetKind())
+		assert.Equal(t, "cookie", rendered[0].Objects[0].GetNamespace())
+		assert.Equal(t, "Namespace", rendered[0].Objects[1].GetKind())
+
+		t.Run("write", func(t *testing.T) {
+			// given
+			err := addFile(afs, "/path/to/rendered/cookie/stale.yaml", `stale`)
+			require.NoError(t, err)
+
+			// when
+			err = validation.WriteRenderedApplications(logger, afs, "/path/to/rendered", rendered)
+
+			// then
+			require.NoError(t, err)
+			files, err := afs.ReadDir("/path/to/rendered/cookie")
+			require.NoError(t, err)
+			require.Len(t, files, 2)
+			assert.Equal(t, "configmap-cookie.yaml", files[0].Name())
+			assert.Equal(t, "namespace-pasta.yaml", files[1].Name())
+			data, err := afs.ReadFile("/path/to/rendered/cookie/configmap-cookie.yaml")
+			require.NoError(t, err)
+			assert.Equal(t, `apiVersion: v1
+data:
+  cookie: yummy
+kind: ConfigMap
+metadata:
+  name: cookie
+  namespace: cookie
+`, string(data))
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t)
+		err := addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- missing.yaml`)
+		require.NoError(t, err)
+
+		// when
+		_, err = validation.RenderApplications(logger, afs, validation.Options{}, "/path/to", "apps")
+
+		// then
+		require.ErrorContains(t, err, "failed to render Application 'cookie' in /path/to/apps/cookie.yaml")
+	})
+	t.Run("write failure", func(t *testing.T) {
+
+		newRendered := func(t *testing.T, names ...string) []validation.RenderedApplication {
+			rendered := []validation.RenderedApplication{}
+			for i, name := range names {
+				obj, err := yaml.Parse(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: cookie`)
+				require.NoError(t, err)
+				rendered = append(rendered, validation.RenderedApplication{
+					Name:    name,
+					Path:    fmt.Sprintf("/path/to/apps/app-%d.yaml", i),
+					Objects: []*yaml.RNode{obj},
+				})
+			}
+			return rendered
+		}
+
+		for _, name := range []string{"", "..", "../cookie", "cookie/pasta"} {
+			t.Run(fmt.Sprintf("invalid name '%s'", name), func(t *testing.T) {
+				// given
+				logger := NewTestLogger(os.Stdout, charmlog.Options{
+					Level: charmlog.InfoLevel,
+				})
+				afs := newFS(t)
+				err := addFile(afs, "/path/to/rendered/pasta/namespace-pasta.yaml", `kept`)
+				require.NoError(t, err)
+
+				// when
+				err = validation.WriteRenderedApplications(logger, afs, "/path/to/rendered", newRendered(t, "pasta", name))
+
+				// then
+				require.EqualError(t, err, fmt.Sprintf("invalid name of the Application in /path/to/apps/app-1.yaml: '%s' is not a valid DNS-1123 subdomain", name))
+				// nothing was removed or written
+				data, err := afs.ReadFile("/path/to/rendered/pasta/namespace-pasta.yaml")
+				require.NoError(t, err)
+				assert.Equal(t, "kept", string(data))
+				exists, err := afs.Exists("/path/to/rendered/pasta/namespace-cookie.yaml")
+				require.NoError(t, err)
+				assert.False(t, exists)
+			})
+		}
+
+		t.Run("duplicate names", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.WriteRenderedApplications(logger, afs, "/path/to/rendered", newRendered(t, "cookie", "cookie"))
+
+			// then
+			require.EqualError(t, err, "duplicate Application name 'cookie' in /path/to/apps/app-0.yaml and /path/to/apps/app-1.yaml")
+			exists, err := afs.Exists("/path/to/rendered/cookie")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	})
+}
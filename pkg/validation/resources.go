@@ -1,6 +1,8 @@
 package validation
 
 import (
+	"fmt"
+	iofs "io/fs"
 	"path/filepath"
 	"strings"
 
@@ -8,84 +10,66 @@ import (
 	"sigs.k8s.io/kustomize/api/types"
 )
 
-// compares the entries of `resources` in the Kustomize file with the contents in the current directory to see if
-// any local file is missing (not referenced as a resource). Files starting with an underscore character (`_`) are
-// ingored
-func checkKustomizeResources(logger Logger, afs afero.Afero, path string) error {
-	logger.Debug("checking kustomization resource", "path", path)
-	data, err := afs.ReadFile(path)
-	if err != nil {
-		return err
-	}
-	var kobj types.Kustomization
-	if err := kobj.Unmarshal(data); err != nil {
-		return err
-	}
-
-	// list resources
-	logger.Debug("checking kustomization resources", "dir", filepath.Dir(path))
-	entries, err := afs.ReadDir(filepath.Dir(path))
-	if err != nil {
-		return err
-	}
-entries:
-	for _, e := range entries {
-		switch {
-		case e.IsDir():
-			fallthrough
-		case e.Name() == filepath.Base(path):
-			fallthrough
-		case strings.HasPrefix(e.Name(), "_"):
-			fallthrough
-		case !(filepath.Ext(e.Name()) == ".yaml" || filepath.Ext(e.Name()) == ".yml"):
-			fallthrough
-		case filepath.Base(e.Name()) == "kustomization.yaml":
-			logger.Debug("ignoring file", "path", path)
-			continue entries
+// compares the files referenced in the Kustomization file with the contents of the current directory (and of its
+// subdirectories which don't have their own Kustomization file) to see if any local file is missing (not referenced).
+// Files and directories starting with an underscore character (`_`) are ingored
+func checkKustomizeResources(logger Logger, r *reporter, afs afero.Afero, path string, kobj types.Kustomization) error {
+	logger.Debug("checking kustomization resources", "path", path)
+	dir := filepath.Dir(path)
+	refs := append(kustomizationReferences(kobj), ksopsReferences(afs, dir, kobj)...)
+	return afs.Walk(dir, func(p string, info iofs.FileInfo, err error) error {
+		if err != nil {
+			return err
 		}
-		for _, r := range kobj.Resources {
-			if r == e.Name() {
-				continue entries
-			}
+		if p == dir {
+			return nil
 		}
-		for _, sg := range kobj.ConfigMapGenerator {
-			for _, f := range sg.FileSources {
-				if i := strings.LastIndex(f, "="); i > 0 {
-					if f[i+1:] == e.Name() {
-						continue entries
-					}
-				} else if f == e.Name() {
-					continue entries
-				}
-			}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
 		}
-		for _, sg := range kobj.SecretGenerator {
-			for _, f := range sg.FileSources {
-				if i := strings.LastIndex(f, "="); i > 0 {
-					if f[i+1:] == e.Name() {
-						continue entries
-					}
-				} else if f == e.Name() {
-					continue entries
-				}
+		switch {
+		case strings.HasPrefix(info.Name(), "_"):
+			logger.Debug("ignoring", "path", p)
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
-		}
-		for _, m := range kobj.PatchesStrategicMerge { //nolint:staticcheck
-			if string(m) == e.Name() {
-				continue entries
+			return nil
+		case info.IsDir():
+			if isReferenced(rel, refs) {
+				return filepath.SkipDir
 			}
-		}
-		for _, m := range kobj.Patches {
-			if string(m.Path) == e.Name() {
-				continue entries
+			if _, found := lookupKustomizationFile(logger, afs, p); found {
+				// checked on its own
+				return filepath.SkipDir
 			}
+			return nil
+		case p == path:
+			fallthrough
+		case !(filepath.Ext(info.Name()) == ".yaml" || filepath.Ext(info.Name()) == ".yml"):
+			logger.Debug("ignoring file", "path", p)
+			return nil
+		case isReferenced(rel, refs):
+			return nil
 		}
-		for _, m := range kobj.Transformers {
-			if string(m) == e.Name() {
-				continue entries
-			}
+		r.report(UnreferencedResourceRule, Finding{
+			Path:       path,
+			Message:    "resource is not referenced",
+			KeyVals:    []interface{}{"resource", rel},
+			Suggestion: fmt.Sprintf("resources:\n- %s", rel),
+		})
+		return nil
+	})
+}
+
+// returns true if the given path (relative to the kustomization directory) is referenced, or is in a referenced
+// directory
+func isReferenced(path string, refs []reference) bool {
+	for _, ref := range refs {
+		p := filepath.Clean(ref.path)
+		if p == path || strings.HasPrefix(path, p+string(filepath.Separator)) {
+			return true
 		}
-		logger.Warn("resource is not referenced", "path", path, "resource", e.Name())
 	}
-	return nil
+	return false
 }
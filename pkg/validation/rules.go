@@ -0,0 +1,505 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Severity is the severity of the findings of a rule
+type Severity string
+
+const (
+	// SeverityWarning findings are logged, but don't fail the run
+	SeverityWarning Severity = "warning"
+	// SeverityError findings fail the run
+	SeverityError Severity = "error"
+)
+
+// Rule is a check with a stable ID (eg: `ACK001`) and a name (eg: `unreferenced-resource`)
+type Rule interface {
+	ID() string
+	Name() string
+	Description() string
+	DefaultSeverity() Severity
+	EnabledByDefault() bool
+}
+
+// ResourcesRule is a Rule which checks the objects rendered by `kustomize build` in the given path
+type ResourcesRule interface {
+	Rule
+	CheckResources(path string, objs []*yaml.RNode) ([]Finding, error)
+}
+
+// ApplicationsRule is a Rule which checks the Applications and ApplicationSets declared in the given file
+type ApplicationsRule interface {
+	Rule
+	CheckApplications(path string, objs []*yaml.RNode) ([]Finding, error)
+}
+
+type rule struct {
+	id          string
+	name        string
+	description string
+	severity    Severity
+	disabled    bool
+}
+
+var _ Rule = rule{}
+
+func (r rule) ID() string {
+	return r.id
+}
+
+func (r rule) Name() string {
+	return r.name
+}
+
+func (r rule) Description() string {
+	return r.description
+}
+
+func (r rule) DefaultSeverity() Severity {
+	return r.severity
+}
+
+func (r rule) EnabledByDefault() bool {
+	return !r.disabled
+}
+
+// built-in rules
+var (
+	UnreferencedResourceRule Rule = rule{
+		id:          "ACK001",
+		name:        "unreferenced-resource",
+		description: "YAML files next to a kustomization file (or in its subdirectories without a kustomization file) must be referenced by the kustomization",
+		severity:    SeverityWarning,
+	}
+	KustomizeBuildRule Rule = rule{
+		id:          "ACK002",
+		name:        "kustomize-build",
+		description: "`kustomize build` must complete successfully",
+		severity:    SeverityError,
+	}
+	InvalidSourcePathRule Rule = rule{
+		id:          "ACK003",
+		name:        "invalid-source-path",
+		description: "the source path of Applications and ApplicationSets must exist",
+		severity:    SeverityError,
+	}
+	MissingReferenceRule Rule = rule{
+		id:          "ACK004",
+		name:        "missing-reference",
+		description: "the files and directories referenced in a kustomization must exist",
+		severity:    SeverityError,
+	}
+	RemoteResourceRule Rule = rule{
+		id:          "ACK005",
+		name:        "remote-resource",
+		description: "the remote resources of a kustomization must be vendored (or skipped) since they can't be fetched",
+		severity:    SeverityError,
+	}
+	FloatingRemoteRefRule Rule = rule{
+		id:          "ACK006",
+		name:        "floating-remote-ref",
+		description: "the remote resources of a kustomization must be pinned to a tag or a commit SHA",
+		severity:    SeverityWarning,
+	}
+	// MissingHelmChartRule reports the Helm charts which are not in the local chart home, when the Helm inflation is enabled
+	MissingHelmChartRule Rule = rule{
+		id:          "ACK007",
+		name:        "missing-helm-chart",
+		description: "the Helm charts of a kustomization must be available in the local chart home",
+		severity:    SeverityError,
+	}
+	// InvalidApplicationNameRule reports the Applications and ApplicationSets whose name is not a valid DNS-1123 subdomain,
+	// including the Applications generated by the list generators of ApplicationSets
+	InvalidApplicationNameRule Rule = rule{
+		id:          "ACK008",
+		name:        "invalid-application-name",
+		description: "the names of the Applications and ApplicationSets must be valid DNS-1123 subdomains",
+		severity:    SeverityError,
+	}
+	// DuplicateApplicationRule reports the Applications with the same name in the same namespace, including the
+	// Applications generated by the list generators of ApplicationSets
+	DuplicateApplicationRule Rule = rule{
+		id:          "ACK009",
+		name:        "duplicate-application",
+		description: "the names of the Applications must be unique in their namespace",
+		severity:    SeverityError,
+	}
+	// ApplicationNamespaceRule reports the Applications and ApplicationSets which are not in the Argo CD namespace
+	ApplicationNamespaceRule Rule = rule{
+		id:          "ACK010",
+		name:        "application-namespace",
+		description: "the Applications and ApplicationSets must be in the Argo CD namespace",
+		severity:    SeverityError,
+	}
+	// MissingFinalizerRule reports the Applications without the resources finalizer, for teams which require cascading deletes
+	MissingFinalizerRule Rule = rule{
+		id:          "ACK011",
+		name:        "missing-finalizer",
+		description: "the Applications must have the `resources-finalizer.argocd.argoproj.io` finalizer, for cascading deletes",
+		severity:    SeverityError,
+		disabled:    true,
+	}
+	// InvalidDestinationRule reports the Applications whose destination has both or neither a server and a name
+	InvalidDestinationRule Rule = rule{
+		id:          "ACK012",
+		name:        "invalid-destination",
+		description: "the destination of the Applications must have either a server or a name",
+		severity:    SeverityError,
+	}
+	// UnknownDestinationRule reports the Applications whose destination is not a declared cluster
+	UnknownDestinationRule Rule = rule{
+		id:          "ACK013",
+		name:        "unknown-destination",
+		description: "the destination of the Applications must be the in-cluster target or a declared cluster",
+		severity:    SeverityError,
+	}
+	// ApplicationCycleRule reports the Applications which deploy themselves through their children (app-of-apps)
+	ApplicationCycleRule Rule = rule{
+		id:          "ACK014",
+		name:        "application-cycle",
+		description: "the Applications must not deploy themselves through their children",
+		severity:    SeverityError,
+	}
+	// InvalidSyncWaveRule reports the rendered objects whose sync wave is not an integer
+	InvalidSyncWaveRule Rule = rule{
+		id:          "ACK015",
+		name:        "invalid-sync-wave",
+		description: "the `argocd.argoproj.io/sync-wave` annotation must be an integer",
+		severity:    SeverityError,
+	}
+	// InvalidHookRule reports the rendered objects with an unknown hook type or hook delete policy
+	InvalidHookRule Rule = rule{
+		id:          "ACK016",
+		name:        "invalid-hook",
+		description: "the `argocd.argoproj.io/hook` and `argocd.argoproj.io/hook-delete-policy` annotations must have known values",
+		severity:    SeverityError,
+	}
+	// SyncOrderRule reports the CRDs and Namespaces which are applied after the objects which depend on them
+	SyncOrderRule Rule = rule{
+		id:          "ACK017",
+		name:        "sync-order",
+		description: "the CRDs and Namespaces must not be applied in a later phase or wave than the objects which depend on them",
+		severity:    SeverityWarning,
+	}
+	// InvalidArgoCDAnnotationRule reports the unknown Argo CD annotations of the rendered objects, and their invalid
+	// values (eg: misspelled sync options), which Argo CD silently ignores
+	InvalidArgoCDAnnotationRule Rule = rule{
+		id:          "ACK018",
+		name:        "invalid-argocd-annotation",
+		description: "the `argocd.argoproj.io/*` annotations of the rendered objects must have known keys and values",
+		severity:    SeverityError,
+	}
+	// PlaintextSecretRule reports the rendered Secrets with plaintext data (eg: from a `secretGenerator`), unless they
+	// are encrypted with SOPS
+	PlaintextSecretRule Rule = rule{
+		id:          "ACK019",
+		name:        "plaintext-secret",
+		description: "the Secrets must not have plaintext data in Git (use SealedSecrets, ExternalSecrets or SOPS instead)",
+		severity:    SeverityError,
+	}
+	// SecretInConfigMapRule reports the ConfigMap values which match a well-known secret pattern or have a high entropy
+	SecretInConfigMapRule Rule = rule{
+		id:          "ACK020",
+		name:        "secret-in-configmap",
+		description: "the ConfigMaps must not contain secrets (private keys, tokens, random values)",
+		severity:    SeverityError,
+	}
+	// KSOPSGeneratorRule reports the KSOPS generators, which can't be built without the SOPS decryption keys unless
+	// they are stubbed
+	KSOPSGeneratorRule Rule = rule{
+		id:          "ACK021",
+		name:        "ksops-generator",
+		description: "the KSOPS generators can't be built without the SOPS decryption keys (use '--stub-sops' to build them with placeholder Secrets)",
+		severity:    SeverityWarning,
+	}
+	// UnpinnedImageRule reports the container images without a tag, or with the `latest` tag
+	UnpinnedImageRule Rule = rule{
+		id:          "ACK022",
+		name:        "unpinned-image",
+		description: "the container images must have a tag (other than 'latest') or a digest",
+		severity:    SeverityWarning,
+	}
+	// DisallowedRegistryRule reports the container images which are not in the allowed registries
+	DisallowedRegistryRule Rule = rule{
+		id:          "ACK023",
+		name:        "disallowed-registry",
+		description: "the container images must be in the allowed registries, when configured",
+		severity:    SeverityError,
+	}
+	// ImageOverrideMismatchRule reports the entries of the `images` transformer of the kustomizations which don't
+	// match the rendered container images
+	ImageOverrideMismatchRule Rule = rule{
+		id:          "ACK024",
+		name:        "image-override-mismatch",
+		description: "the entries of the 'images' transformer of the kustomizations must match the rendered container images",
+		severity:    SeverityError,
+	}
+	// MissingResourcesRule reports the containers without CPU and memory requests and limits
+	MissingResourcesRule Rule = rule{
+		id:          "ACK025",
+		name:        "missing-resources",
+		description: "the containers must have CPU and memory requests and limits",
+		severity:    SeverityWarning,
+		disabled:    true,
+	}
+	// MissingProbesRule reports the containers of the long-running workloads without readiness and liveness probes
+	MissingProbesRule Rule = rule{
+		id:          "ACK026",
+		name:        "missing-probes",
+		description: "the containers of the long-running workloads must have readiness and liveness probes",
+		severity:    SeverityWarning,
+		disabled:    true,
+	}
+	// PrivilegedContainerRule reports the privileged containers
+	PrivilegedContainerRule Rule = rule{
+		id:          "ACK027",
+		name:        "privileged-container",
+		description: "the containers must not be privileged",
+		severity:    SeverityError,
+		disabled:    true,
+	}
+	// HostNetworkRule reports the pods which use the host network
+	HostNetworkRule Rule = rule{
+		id:          "ACK028",
+		name:        "host-network",
+		description: "the pods must not use the host network",
+		severity:    SeverityError,
+		disabled:    true,
+	}
+	// RunAsNonRootRule reports the containers which may run as root, ie: without `runAsNonRoot` in their security
+	// context or in the security context of their pod
+	RunAsNonRootRule Rule = rule{
+		id:          "ACK029",
+		name:        "run-as-non-root",
+		description: "the containers must run as a non-root user ('runAsNonRoot: true')",
+		severity:    SeverityError,
+		disabled:    true,
+	}
+	// UnmatchedSelectorRule reports the Services whose selector doesn't match any rendered pod template
+	UnmatchedSelectorRule Rule = rule{
+		id:          "ACK030",
+		name:        "unmatched-selector",
+		description: "the selector of the Services must match the labels of at least one rendered pod template",
+		severity:    SeverityWarning,
+	}
+	// DanglingReferenceRule reports the references to ConfigMaps, Secrets, ServiceAccounts and Services which are
+	// neither rendered nor declared as external objects
+	DanglingReferenceRule Rule = rule{
+		id:          "ACK031",
+		name:        "dangling-reference",
+		description: "the ConfigMaps, Secrets, ServiceAccounts and Services referenced by the rendered objects must be rendered too, or declared as external objects",
+		severity:    SeverityWarning,
+	}
+	// DeprecatedAPIRule reports the rendered objects whose API is deprecated in the Kubernetes version of the target
+	// clusters
+	DeprecatedAPIRule Rule = rule{
+		id:          "ACK032",
+		name:        "deprecated-api",
+		description: "the rendered objects must not use APIs which are deprecated in the Kubernetes version of the target clusters",
+		severity:    SeverityWarning,
+	}
+	// RemovedAPIRule reports the rendered objects whose API is removed in the Kubernetes version of the target clusters
+	RemovedAPIRule Rule = rule{
+		id:          "ACK033",
+		name:        "removed-api",
+		description: "the rendered objects must not use APIs which are removed in the Kubernetes version of the target clusters",
+		severity:    SeverityError,
+	}
+	// UnknownIgnoredRule reports the IDs or names of the `# argocd-checker:ignore` comments which match neither a rule
+	// nor a policy (eg: a typo), since such comments don't ignore anything
+	UnknownIgnoredRule Rule = rule{
+		id:          "ACK034",
+		name:        "unknown-ignored-rule",
+		description: "the rules of the '# argocd-checker:ignore' comments must be the IDs or names of existing rules or policies",
+		severity:    SeverityWarning,
+	}
+)
+
+// BuiltinRules returns the rules provided by the checker
+func BuiltinRules() []Rule {
+	return []Rule{
+		UnreferencedResourceRule,
+		KustomizeBuildRule,
+		InvalidSourcePathRule,
+		MissingReferenceRule,
+		RemoteResourceRule,
+		FloatingRemoteRefRule,
+		MissingHelmChartRule,
+		InvalidApplicationNameRule,
+		DuplicateApplicationRule,
+		ApplicationNamespaceRule,
+		MissingFinalizerRule,
+		InvalidDestinationRule,
+		UnknownDestinationRule,
+		ApplicationCycleRule,
+		InvalidSyncWaveRule,
+		InvalidHookRule,
+		SyncOrderRule,
+		InvalidArgoCDAnnotationRule,
+		PlaintextSecretRule,
+		SecretInConfigMapRule,
+		KSOPSGeneratorRule,
+		UnpinnedImageRule,
+		DisallowedRegistryRule,
+		ImageOverrideMismatchRule,
+		MissingResourcesRule,
+		MissingProbesRule,
+		PrivilegedContainerRule,
+		HostNetworkRule,
+		RunAsNonRootRule,
+		UnmatchedSelectorRule,
+		DanglingReferenceRule,
+		DeprecatedAPIRule,
+		RemovedAPIRule,
+		UnknownIgnoredRule,
+	}
+}
+
+// Registry holds the rules along with their settings (enabled/disabled and severity)
+type Registry struct {
+	rules      []Rule
+	enabled    map[string]bool
+	severities map[string]Severity
+}
+
+// NewRegistry returns a new registry with the built-in rules
+func NewRegistry() *Registry {
+	r := &Registry{
+		enabled:    map[string]bool{},
+		severities: map[string]Severity{},
+	}
+	if err := r.Register(BuiltinRules()...); err != nil {
+		panic(err) // built-in rules have unique IDs
+	}
+	return r
+}
+
+// Register adds the given rules in the registry. Returns an error if a rule with the same ID or name already exists
+func (r *Registry) Register(rules ...Rule) error {
+	for _, rl := range rules {
+		if _, found := r.Lookup(rl.ID()); found {
+			return fmt.Errorf("rule '%s' is already registered", rl.ID())
+		}
+		if _, found := r.Lookup(rl.Name()); found {
+			return fmt.Errorf("rule '%s' is already registered", rl.Name())
+		}
+		r.rules = append(r.rules, rl)
+		r.enabled[rl.ID()] = rl.EnabledByDefault()
+		r.severities[rl.ID()] = rl.DefaultSeverity()
+	}
+	return nil
+}
+
+// Rules returns all the registered rules, in the order of registration
+func (r *Registry) Rules() []Rule {
+	return r.rules
+}
+
+// Lookup returns the rule with the given ID or name
+func (r *Registry) Lookup(idOrName string) (Rule, bool) {
+	for _, rl := range r.rules {
+		if rl.ID() == idOrName || rl.Name() == idOrName {
+			return rl, true
+		}
+	}
+	return nil, false
+}
+
+// SetEnabled enables or disables the rule with the given ID or name
+func (r *Registry) SetEnabled(idOrName string, enabled bool) error {
+	rl, found := r.Lookup(idOrName)
+	if !found {
+		return fmt.Errorf("unknown rule '%s'", idOrName)
+	}
+	r.enabled[rl.ID()] = enabled
+	return nil
+}
+
+// SetSeverity overrides the severity of the rule with the given ID or name
+func (r *Registry) SetSeverity(idOrName string, severity Severity) error {
+	rl, found := r.Lookup(idOrName)
+	if !found {
+		return fmt.Errorf("unknown rule '%s'", idOrName)
+	}
+	if severity != SeverityWarning && severity != SeverityError {
+		return fmt.Errorf("invalid severity '%s' for rule '%s'", severity, idOrName)
+	}
+	r.severities[rl.ID()] = severity
+	return nil
+}
+
+// Enabled returns true if the given rule is enabled
+func (r *Registry) Enabled(rl Rule) bool {
+	if enabled, found := r.enabled[rl.ID()]; found {
+		return enabled
+	}
+	return rl.EnabledByDefault()
+}
+
+// Severity returns the severity of the given rule
+func (r *Registry) Severity(rl Rule) Severity {
+	if s, found := r.severities[rl.ID()]; found {
+		return s
+	}
+	return rl.DefaultSeverity()
+}
+
+// Configure applies the given settings to the rules
+func (r *Registry) Configure(rules map[string]RuleConfig) error {
+	for id, cfg := range rules {
+		if cfg.Enabled != nil {
+			if err := r.SetEnabled(id, *cfg.Enabled); err != nil {
+				return err
+			}
+		}
+		if cfg.Severity != "" {
+			if err := r.SetSeverity(id, cfg.Severity); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matches the comma-separated IDs or names of the rules to ignore, without the text which may follow on the same line
+// (eg: `# argocd-checker:ignore ACK012, ACK013 legacy chart`)
+var ignoreRegexp = regexp.MustCompile(`#[ \t]*argocd-checker:ignore[ \t]+([A-Za-z0-9_-]+(?:[ \t]*,[ \t]*[A-Za-z0-9_-]+)*)`)
+
+// returns the IDs or names of the rules to ignore, as specified in `# argocd-checker:ignore <rule>[,<rule>]` comments
+func ignoredRules(data []byte) []string {
+	ignored := []string{}
+	for _, m := range ignoreRegexp.FindAllSubmatch(data, -1) {
+		for _, id := range strings.Split(string(m[1]), ",") {
+			ignored = append(ignored, strings.TrimSpace(id))
+		}
+	}
+	return ignored
+}
+
+// reports the given IDs or names of ignored rules which match neither a rule nor a policy of the registry
+func checkIgnoredRules(r *reporter, path string, ignored []string) {
+	for _, id := range ignored {
+		if _, found := r.rules.Lookup(id); found {
+			continue
+		}
+		f := Finding{
+			Path:    path,
+			Message: "unknown rule in ignore comment",
+			KeyVals: []interface{}{"ignored", id},
+		}
+		candidates := []string{}
+		for _, rl := range r.rules.Rules() {
+			candidates = append(candidates, rl.ID(), rl.Name())
+		}
+		if s, found := closest(id, candidates); found {
+			f.Suggestion = fmt.Sprintf("argocd-checker:ignore %s", s)
+		}
+		r.report(UnknownIgnoredRule, f)
+	}
+}
@@ -0,0 +1,324 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+
+	t.Run("built-in rules", func(t *testing.T) {
+		// when
+		rules := validation.NewRegistry()
+
+		// then
+		r, found := rules.Lookup("ACK001")
+		require.True(t, found)
+		assert.Equal(t, "unreferenced-resource", r.Name())
+		assert.True(t, rules.Enabled(r))
+		assert.Equal(t, validation.SeverityWarning, rules.Severity(r))
+		r, found = rules.Lookup("kustomize-build")
+		require.True(t, found)
+		assert.Equal(t, "ACK002", r.ID())
+		assert.Equal(t, validation.SeverityError, rules.Severity(r))
+	})
+
+	t.Run("configure", func(t *testing.T) {
+		// given
+		rules := validation.NewRegistry()
+		disabled := false
+
+		// when
+		err := rules.Configure(map[string]validation.RuleConfig{
+			"ACK001": {
+				Severity: validation.SeverityError,
+			},
+			"kustomize-build": {
+				Enabled: &disabled,
+			},
+		})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, validation.SeverityError, rules.Severity(validation.UnreferencedResourceRule))
+		assert.False(t, rules.Enabled(validation.KustomizeBuildRule))
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("duplicate rule", func(t *testing.T) {
+			// given
+			rules := validation.NewRegistry()
+
+			// when
+			err := rules.Register(validation.UnreferencedResourceRule)
+
+			// then
+			require.EqualError(t, err, "rule 'ACK001' is already registered")
+		})
+
+		t.Run("unknown rule", func(t *testing.T) {
+			// given
+			rules := validation.NewRegistry()
+
+			// when
+			err := rules.SetSeverity("ACK999", validation.SeverityError)
+
+			// then
+			require.EqualError(t, err, "unknown rule 'ACK999'")
+		})
+
+		t.Run("invalid severity", func(t *testing.T) {
+			// given
+			rules := validation.NewRegistry()
+
+			// when
+			err := rules.SetSeverity("ACK001", "fatal")
+
+			// then
+			require.EqualError(t, err, "invalid severity 'fatal' for rule 'ACK001'")
+		})
+	})
+}
+
+func TestRuleSettings(t *testing.T) {
+
+	newFS := func(t *testing.T, kustomization string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/kustomization.yaml", kustomization)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cm
+data:
+  cookie: yummy`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/unused.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: unused
+data:
+  cookie: yummy`)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("promote warning to error", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+		rules := validation.NewRegistry()
+		err := rules.SetSeverity("unreferenced-resource", validation.SeverityError)
+		require.NoError(t, err)
+
+		// when
+		err = validation.CheckComponents(logger, afs, validation.Options{Rules: rules}, "/path/to", "components")
+
+		// then
+		require.EqualError(t, err, "found 1 error(s)")
+		assert.Empty(t, logger.Warnings())
+		assert.Contains(t, logger.Errors(), LogRecord{
+			Msg: "resource is not referenced",
+			KeyVals: []interface{}{
+				"rule", "ACK001",
+				"path", "/path/to/components/kustomization.yaml",
+				"resource", "unused.yaml",
+				"suggestion", "resources:\n- unused.yaml",
+			},
+		})
+	})
+
+	t.Run("strict mode", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{Strict: true}, "/path/to", "components")
+
+		// then
+		require.EqualError(t, err, "found 1 error(s)")
+		assert.Empty(t, logger.Warnings())
+		assert.Contains(t, logger.Errors(), LogRecord{
+			Msg: "resource is not referenced",
+			KeyVals: []interface{}{
+				"rule", "ACK001",
+				"path", "/path/to/components/kustomization.yaml",
+				"resource", "unused.yaml",
+				"suggestion", "resources:\n- unused.yaml",
+			},
+		})
+	})
+
+	t.Run("disabled rule", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+		rules := validation.NewRegistry()
+		err := rules.SetEnabled("ACK001", false)
+		require.NoError(t, err)
+
+		// when
+		err = validation.CheckComponents(logger, afs, validation.Options{Rules: rules}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("inline ignore", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `# argocd-checker:ignore ACK001
+kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("inline ignore with multiple rules", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml
+- missing.yaml # argocd-checker:ignore unreferenced-resource, ACK002, missing-reference`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+	t.Run("inline ignore with trailing text", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `# argocd-checker:ignore ACK001 legacy chart, see TICKET-1
+kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("inline ignore of a policy", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `# argocd-checker:ignore ACK001, no-cookie
+kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+		err := addFile(afs, "/path/to/policies/policies.yaml", `policies:
+- name: no-cookie
+  kinds:
+  - ConfigMap
+  expression: "!has(object.data.cookie)"`)
+		require.NoError(t, err)
+		policies, err := validation.LoadPolicies(logger, afs, "/path/to/policies")
+		require.NoError(t, err)
+		rules := validation.NewRegistry()
+		for _, p := range policies {
+			require.NoError(t, rules.Register(p.Rule()))
+		}
+
+		// when
+		err = validation.CheckComponents(logger, afs, validation.Options{Rules: rules}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("inline ignore of an unknown rule", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `# argocd-checker:ignore ACK01, ACK002
+kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Equal(t, []LogRecord{
+			{
+				Msg: "unknown rule in ignore comment",
+				KeyVals: []interface{}{
+					"rule", "ACK034",
+					"path", "/path/to/components/kustomization.yaml",
+					"ignored", "ACK01",
+					"suggestion", "argocd-checker:ignore ACK001",
+				},
+			},
+			{
+				Msg: "resource is not referenced",
+				KeyVals: []interface{}{
+					"rule", "ACK001",
+					"path", "/path/to/components/kustomization.yaml",
+					"resource", "unused.yaml",
+					"suggestion", "resources:\n- unused.yaml",
+				},
+			},
+		}, logger.Warnings())
+	})
+}
@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// patterns of the well-known secrets, indexed by description
+var secretPatterns = map[string]*regexp.Regexp{
+	"private key":         regexp.MustCompile(`-----BEGIN ([A-Z]+ )?PRIVATE KEY( BLOCK)?-----`),
+	"AWS access key":      regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`),
+	"GitHub token":        regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`),
+	"GitLab token":        regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}\b`),
+	"Slack token":         regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}\b`),
+	"JSON Web Token":      regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}\b`),
+	"Google API key":      regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`),
+	"password in a URL":   regexp.MustCompile(`[a-z][a-z0-9+.\-]*://[^/\s:@]+:[^/\s:@]+@`),
+	"Stripe secret key":   regexp.MustCompile(`\bsk_live_[0-9a-zA-Z]{24,}\b`),
+	"SendGrid API key":    regexp.MustCompile(`\bSG\.[A-Za-z0-9_\-]{22}\.[A-Za-z0-9_\-]{43}\b`),
+	"Azure storage key":   regexp.MustCompile(`AccountKey=[A-Za-z0-9+/=]{40,}`),
+	"npm access token":    regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`),
+	"Docker Hub token":    regexp.MustCompile(`\bdckr_pat_[A-Za-z0-9_\-]{27,}\b`),
+	"Hashicorp Vault key": regexp.MustCompile(`\bhvs\.[A-Za-z0-9_\-]{24,}\b`),
+}
+
+const (
+	// minimum length of the values whose entropy is computed
+	entropyMinLength = 24
+	// Shannon entropy (in bits per character) above which a value looks like a random secret
+	entropyThreshold = 4.2
+)
+
+// reports the Secrets with plaintext data, unless they are encrypted with SOPS, and the ConfigMap values which look
+// like secrets. The values are never reported.
+func checkSecrets(r *reporter, path string, objs []*yaml.RNode) {
+	for _, obj := range objs {
+		if obj.GetApiVersion() != "v1" {
+			// eg: SealedSecrets and ExternalSecrets
+			continue
+		}
+		switch obj.GetKind() {
+		case "Secret":
+			if isSOPSEncrypted(obj) {
+				continue
+			}
+			keys := []string{}
+			for _, field := range []string{"data", "stringData"} {
+				for key, value := range fieldValues(obj, field) {
+					if value == "" || strings.HasPrefix(value, "ENC[") {
+						continue
+					}
+					keys = append(keys, key)
+				}
+			}
+			if len(keys) == 0 {
+				continue
+			}
+			r.report(PlaintextSecretRule, Finding{
+				Path:       path,
+				Message:    "Secret has plaintext data in Git",
+				KeyVals:    []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "keys", sortedKeys(toSet(keys))},
+				Suggestion: "use a SealedSecret or an ExternalSecret, or encrypt the file with SOPS",
+			})
+		case "ConfigMap":
+			values := fieldValues(obj, "data")
+			for _, key := range sortedKeys(values) {
+				if reason, found := secretLike(values[key]); found {
+					r.report(SecretInConfigMapRule, Finding{
+						Path:    path,
+						Message: "ConfigMap value looks like a secret",
+						KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "key", key, "reason", reason},
+					})
+				}
+			}
+		}
+	}
+}
+
+// returns the string values of the given map field of the object
+func fieldValues(obj *yaml.RNode, field string) map[string]string {
+	values := map[string]string{}
+	f := obj.Field(field)
+	if f == nil || f.Value.YNode().Kind != yaml.MappingNode {
+		return values
+	}
+	_ = f.Value.VisitFields(func(node *yaml.MapNode) error {
+		values[yaml.GetValue(node.Key)] = yaml.GetValue(node.Value)
+		return nil
+	})
+	return values
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// returns the reason why the given value looks like a secret (a well-known pattern or a high entropy), if any
+func secretLike(value string) (string, bool) {
+	for _, desc := range sortedKeys(secretPatterns) {
+		if secretPatterns[desc].MatchString(value) {
+			return desc, true
+		}
+	}
+	for _, word := range strings.Fields(value) {
+		word = strings.Trim(word, `"',;`)
+		if len(word) < entropyMinLength || strings.Contains(word, "://") || strings.Contains(word, "@sha256:") {
+			// eg: URLs and image digests
+			continue
+		}
+		if entropy(word) >= entropyThreshold {
+			return "high entropy", true
+		}
+	}
+	return "", false
+}
+
+// returns the Shannon entropy of the given value, in bits per character
+func entropy(value string) float64 {
+	counts := map[rune]int{}
+	for _, c := range value {
+		counts[c]++
+	}
+	e := 0.0
+	n := float64(len([]rune(value)))
+	for _, c := range counts {
+		p := float64(c) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
@@ -0,0 +1,252 @@
+package validation
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/types"
+	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	k8syaml "sigs.k8s.io/yaml"
+)
+
+// ksopsConfig is the configuration of a KSOPS generator (https://github.com/viaduct-ai/kustomize-sops)
+type ksopsConfig struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	// Files are the SOPS-encrypted Secret manifests
+	Files []string `json:"files,omitempty"`
+	// SecretFrom are the Secrets generated from SOPS-encrypted files
+	SecretFrom []ksopsSecret `json:"secretFrom,omitempty"`
+}
+
+// ksopsSecret is a Secret generated by KSOPS from SOPS-encrypted files (`[{key}=]{path}`)
+type ksopsSecret struct {
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace,omitempty"`
+		Labels      map[string]string `json:"labels,omitempty"`
+		Annotations map[string]string `json:"annotations,omitempty"`
+	} `json:"metadata"`
+	Type        string   `json:"type,omitempty"`
+	Files       []string `json:"files,omitempty"`
+	BinaryFiles []string `json:"binaryFiles,omitempty"`
+}
+
+// returns true if the given object was encrypted with SOPS
+func isSOPSEncrypted(obj *yaml.RNode) bool {
+	f := obj.Field("sops")
+	return f != nil && f.Value.YNode().Kind == yaml.MappingNode
+}
+
+// returns the KSOPS generators of the given kustomization, indexed by path (relative to the kustomization directory).
+// Invalid and missing generator files are skipped, since they are reported when the kustomization is checked.
+func ksopsGenerators(readFile func(string) ([]byte, error), dir string, kobj types.Kustomization) map[string][]ksopsConfig {
+	generators := map[string][]ksopsConfig{}
+	for _, g := range kobj.Generators {
+		if strings.Contains(g, "\n") || isRemoteResource(g) {
+			continue
+		}
+		data, err := readFile(filepath.Join(dir, g))
+		if err != nil {
+			continue
+		}
+		objs, err := readObjects(data)
+		if err != nil {
+			continue
+		}
+		for _, obj := range objs {
+			if !strings.HasPrefix(obj.GetApiVersion(), "viaduct.ai/") || !strings.EqualFold(obj.GetKind(), "ksops") {
+				continue
+			}
+			cfg := ksopsConfig{}
+			if err := decode(obj, &cfg); err != nil {
+				continue
+			}
+			generators[g] = append(generators[g], cfg)
+		}
+	}
+	return generators
+}
+
+// returns the SOPS-encrypted files referenced by the KSOPS generators of the given kustomization
+func ksopsReferences(afs afero.Afero, dir string, kobj types.Kustomization) []reference {
+	refs := []reference{}
+	for _, cfgs := range ksopsGenerators(afs.ReadFile, dir, kobj) {
+		for _, cfg := range cfgs {
+			for _, f := range cfg.Files {
+				refs = append(refs, reference{field: "generators.files", path: f})
+			}
+			for _, s := range cfg.SecretFrom {
+				for _, f := range fileSourcePaths(append(append([]string{}, s.Files...), s.BinaryFiles...)) {
+					refs = append(refs, reference{field: "generators.secretFrom.files", path: f})
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// reports the KSOPS generators of the given kustomization, which can't be built without the SOPS decryption keys
+// (unless they are stubbed)
+func checkKSOPSGenerators(r *reporter, afs afero.Afero, opts Options, path string, kobj types.Kustomization) {
+	if opts.StubSOPS {
+		return
+	}
+	generators := ksopsGenerators(afs.ReadFile, filepath.Dir(path), kobj)
+	for _, g := range sortedKeys(generators) {
+		r.report(KSOPSGeneratorRule, Finding{
+			Path:       path,
+			Message:    "KSOPS generator can't be built without the SOPS decryption keys",
+			KeyVals:    []interface{}{"generator", g},
+			Suggestion: "use the '--stub-sops' flag to build the kustomization with placeholder Secrets",
+		})
+	}
+}
+
+// replaces the KSOPS generators of the kustomizations in the given directory with placeholder Secrets (with the
+// same name, namespace, type and keys, but empty values), so that the kustomizations can be built without the SOPS
+// decryption keys. The files are only modified in the in-memory filesystem.
+func resolveSOPS(logger Logger, fsys kfsys.FileSystem, opts Options, baseDir string) error {
+	if !opts.StubSOPS || !fsys.Exists(baseDir) {
+		return nil
+	}
+	kustomizations, err := lookupKustomizationFiles(fsys, baseDir)
+	if err != nil {
+		return err
+	}
+	for _, path := range kustomizations {
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var kobj types.Kustomization
+		if err := kobj.Unmarshal(data); err != nil {
+			// invalid kustomizations are reported when they are checked
+			continue
+		}
+		dir := filepath.Dir(path)
+		generators := ksopsGenerators(fsys.ReadFile, dir, kobj)
+		if len(generators) == 0 {
+			continue
+		}
+		remaining := []string{}
+		for _, g := range kobj.Generators {
+			cfgs, found := generators[g]
+			if !found {
+				remaining = append(remaining, g)
+				continue
+			}
+			stubs := []string{}
+			for _, cfg := range cfgs {
+				s, err := stubSecrets(fsys, dir, cfg)
+				if err != nil {
+					return fmt.Errorf("failed to stub KSOPS generator %s: %w", filepath.Join(dir, g), err)
+				}
+				stubs = append(stubs, s...)
+			}
+			stub := strings.TrimSuffix(g, filepath.Ext(g)) + ".sops-stub.yaml"
+			logger.Debug("stubbing KSOPS generator", "path", path, "generator", g, "secrets", len(stubs))
+			if err := fsys.WriteFile(filepath.Join(dir, stub), []byte(strings.Join(stubs, "---\n"))); err != nil {
+				return err
+			}
+			kobj.Resources = append(kobj.Resources, stub)
+		}
+		kobj.Generators = remaining
+		if data, err = k8syaml.Marshal(kobj); err != nil {
+			return err
+		}
+		if err := fsys.WriteFile(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returns the placeholder Secrets of the given KSOPS generator
+func stubSecrets(fsys kfsys.FileSystem, dir string, cfg ksopsConfig) ([]string, error) {
+	stubs := []string{}
+	for _, f := range cfg.Files {
+		data, err := fsys.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			return nil, err
+		}
+		objs, err := readObjects(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SOPS-encrypted file %s: %w", f, err)
+		}
+		for _, obj := range objs {
+			if obj.GetKind() != "Secret" {
+				continue
+			}
+			keys := []string{}
+			for _, field := range []string{"data", "stringData"} {
+				keys = append(keys, sortedKeys(fieldValues(obj, field))...)
+			}
+			if strings.HasPrefix(obj.GetName(), "ENC[") {
+				return nil, fmt.Errorf("the metadata of %s is encrypted (only the 'data' and 'stringData' should be, eg: with `encrypted_regex: ^(data|stringData)$`)", f)
+			}
+			secretType, _ := obj.GetString("type")
+			s, err := stubSecret(obj.GetName(), obj.GetNamespace(), secretType, obj.GetLabels(), obj.GetAnnotations(), keys)
+			if err != nil {
+				return nil, err
+			}
+			stubs = append(stubs, s)
+		}
+	}
+	for _, sf := range cfg.SecretFrom {
+		keys := []string{}
+		for _, f := range append(append([]string{}, sf.Files...), sf.BinaryFiles...) {
+			if i := strings.Index(f, "="); i > 0 {
+				keys = append(keys, f[:i])
+			} else {
+				keys = append(keys, filepath.Base(f))
+			}
+		}
+		s, err := stubSecret(sf.Metadata.Name, sf.Metadata.Namespace, sf.Type, sf.Metadata.Labels, sf.Metadata.Annotations, keys)
+		if err != nil {
+			return nil, err
+		}
+		stubs = append(stubs, s)
+	}
+	return stubs, nil
+}
+
+// returns a placeholder Secret with the given metadata, type and keys (with empty values)
+func stubSecret(name, namespace, secretType string, labels, annotations map[string]string, keys []string) (string, error) {
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+	}
+	metadata := map[string]interface{}{
+		"name": name,
+	}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	for field, values := range map[string]map[string]string{"labels": labels, "annotations": annotations} {
+		plain := map[string]string{}
+		for k, v := range values {
+			if !strings.HasPrefix(v, "ENC[") {
+				plain[k] = v
+			}
+		}
+		if len(plain) > 0 {
+			metadata[field] = plain
+		}
+	}
+	secret["metadata"] = metadata
+	if secretType != "" {
+		secret["type"] = secretType
+	}
+	data := map[string]string{}
+	for _, k := range keys {
+		data[k] = ""
+	}
+	secret["data"] = data
+	out, err := k8syaml.Marshal(secret)
+	return string(out), err
+}
@@ -0,0 +1,196 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSOPS(t *testing.T) {
+
+	newFS := func(t *testing.T) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		for path, content := range map[string]string{
+			"/path/to/components/cookie/kustomization.yaml": `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- configmap.yaml
+generators:
+- secret-generator.yaml`,
+			"/path/to/components/cookie/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie`,
+			"/path/to/components/cookie/secret-generator.yaml": `apiVersion: viaduct.ai/v1
+kind: ksops
+metadata:
+  name: secret-generator
+  annotations:
+    config.kubernetes.io/function: |
+      exec:
+        path: ksops
+files:
+- secret.enc.yaml
+secretFrom:
+- metadata:
+    name: pasta
+    namespace: cookie
+  type: kubernetes.io/tls
+  files:
+  - tls.crt=tls.enc.crt
+  - tls.enc.key`,
+			"/path/to/components/cookie/secret.enc.yaml": `apiVersion: v1
+kind: Secret
+metadata:
+  name: cookie
+  labels:
+    app: cookie
+stringData:
+  recipe: ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:k=,type:str]
+sops:
+  version: 3.8.1
+  encrypted_regex: ^(data|stringData)$`,
+			"/path/to/components/cookie/tls.enc.crt": `ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:k=,type:str]`,
+			"/path/to/components/cookie/tls.enc.key": `ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:k=,type:str]`,
+		} {
+			err := addFile(afs, path, content)
+			require.NoError(t, err)
+		}
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+
+		t.Run("stubbed KSOPS generator", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{StubSOPS: true}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+
+		t.Run("rendered placeholder Secrets", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+			err := addFile(afs, "/path/to/apps/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`)
+			require.NoError(t, err)
+
+			// when
+			apps, err := validation.RenderApplications(logger, afs, validation.Options{StubSOPS: true}, "/path/to", "apps")
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, apps, 1)
+			secrets := map[string]string{}
+			for _, obj := range apps[0].Objects {
+				if obj.GetKind() != "Secret" {
+					continue
+				}
+				s, err := obj.String()
+				require.NoError(t, err)
+				secrets[obj.GetName()] = s
+			}
+			assert.Equal(t, map[string]string{
+				"cookie": `apiVersion: v1
+data:
+  recipe: ""
+kind: Secret
+metadata:
+  labels:
+    app: cookie
+  name: cookie
+`,
+				"pasta": `apiVersion: v1
+data:
+  tls.crt: ""
+  tls.enc.key: ""
+kind: Secret
+metadata:
+  name: pasta
+  namespace: cookie
+type: kubernetes.io/tls
+`,
+			}, secrets)
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("KSOPS generator without stub", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Warnings(), 1)
+			assert.Equal(t, LogRecord{
+				Msg: "KSOPS generator can't be built without the SOPS decryption keys",
+				KeyVals: []interface{}{
+					"rule", "ACK021",
+					"path", "/path/to/components/cookie/kustomization.yaml",
+					"generator", "secret-generator.yaml",
+					"suggestion", "use the '--stub-sops' flag to build the kustomization with placeholder Secrets",
+				},
+			}, logger.Warnings()[0])
+			// the encrypted files are referenced by the generator, but the kustomization can't be built
+			require.Len(t, logger.Errors(), 1)
+			assert.Equal(t, "kustomize build failed", logger.Errors()[0].Msg)
+		})
+
+		t.Run("encrypted metadata", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t)
+			err := addFile(afs, "/path/to/components/cookie/secret.enc.yaml", `apiVersion: v1
+kind: Secret
+metadata:
+  name: ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:k=,type:str]
+stringData:
+  recipe: ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:k=,type:str]
+sops:
+  version: 3.8.1`)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{StubSOPS: true}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "failed to stub KSOPS generator /path/to/components/cookie/secret-generator.yaml: the metadata of secret.enc.yaml is encrypted (only the 'data' and 'stringData' should be, eg: with `encrypted_regex: ^(data|stringData)$`)")
+		})
+	})
+}
@@ -0,0 +1,303 @@
+package validation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// SyncWaveAnnotation is the annotation with the sync wave of an object
+	SyncWaveAnnotation = "argocd.argoproj.io/sync-wave"
+	// HookAnnotation is the annotation with the hook type(s) of an object
+	HookAnnotation = "argocd.argoproj.io/hook"
+	// HookDeletePolicyAnnotation is the annotation with the delete policies of a hook
+	HookDeletePolicyAnnotation = "argocd.argoproj.io/hook-delete-policy"
+)
+
+// SyncPhase is a phase of an Argo CD sync
+type SyncPhase string
+
+const (
+	// SyncPhasePreSync runs the hooks before the objects are applied
+	SyncPhasePreSync SyncPhase = "PreSync"
+	// SyncPhaseSync applies the objects (and runs the `Sync` hooks)
+	SyncPhaseSync SyncPhase = "Sync"
+	// SyncPhasePostSync runs the hooks after the objects are applied and healthy
+	SyncPhasePostSync SyncPhase = "PostSync"
+	// SyncPhaseSyncFail runs the hooks when the sync fails
+	SyncPhaseSyncFail SyncPhase = "SyncFail"
+	// SyncPhasePostDelete runs the hooks after the Application is deleted
+	SyncPhasePostDelete SyncPhase = "PostDelete"
+)
+
+// the phases, in the order in which they run
+var syncPhases = []SyncPhase{SyncPhasePreSync, SyncPhaseSync, SyncPhasePostSync, SyncPhaseSyncFail, SyncPhasePostDelete}
+
+// the hook type of the objects which are not applied by Argo CD
+const skipHook = "Skip"
+
+var hookDeletePolicies = map[string]bool{
+	"HookSucceeded":      true,
+	"HookFailed":         true,
+	"BeforeHookCreation": true,
+}
+
+// the order in which Argo CD applies the kinds of a same wave (see gitops-engine's `kindOrder`).
+// Other kinds are applied after these ones.
+var syncKindOrder = []string{
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodSecurityPolicy",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"SecretList",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleList",
+	"ClusterRoleBinding",
+	"ClusterRoleBindingList",
+	"Role",
+	"RoleList",
+	"RoleBinding",
+	"RoleBindingList",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicationController",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+}
+
+// SyncStep is an object applied by Argo CD during a sync, along with its phase and wave
+type SyncStep struct {
+	Phase     SyncPhase
+	Wave      int
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// returns the sync wave of the given object (0 if not set), and false if the annotation is not an integer
+func syncWave(obj *yaml.RNode) (int, bool) {
+	w, found := obj.GetAnnotations()[SyncWaveAnnotation]
+	if !found {
+		return 0, true
+	}
+	wave, err := strconv.Atoi(strings.TrimSpace(w))
+	if err != nil {
+		return 0, false
+	}
+	return wave, true
+}
+
+// returns the phases in which the given object is applied, based on its hook annotation
+// (the `Sync` phase if it is not a hook, none if it is skipped)
+func syncPhasesOf(obj *yaml.RNode) []SyncPhase {
+	hook, found := obj.GetAnnotations()[HookAnnotation]
+	if !found {
+		return []SyncPhase{SyncPhaseSync}
+	}
+	phases := []SyncPhase{}
+	for _, h := range strings.Split(hook, ",") {
+		for _, p := range syncPhases {
+			if strings.TrimSpace(h) == string(p) {
+				phases = append(phases, p)
+			}
+		}
+	}
+	return phases
+}
+
+func phaseIndex(p SyncPhase) int {
+	for i, phase := range syncPhases {
+		if phase == p {
+			return i
+		}
+	}
+	return len(syncPhases)
+}
+
+func kindIndex(kind string) int {
+	for i, k := range syncKindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(syncKindOrder)
+}
+
+// SyncOrder returns the steps in which Argo CD applies the given objects: by phase, then by wave, then by kind
+// and by name. Skipped hooks are omitted.
+func SyncOrder(objs []*yaml.RNode) []SyncStep {
+	steps := []SyncStep{}
+	for _, obj := range objs {
+		wave, _ := syncWave(obj)
+		for _, p := range syncPhasesOf(obj) {
+			steps = append(steps, SyncStep{
+				Phase:     p,
+				Wave:      wave,
+				Kind:      obj.GetKind(),
+				Namespace: obj.GetNamespace(),
+				Name:      obj.GetName(),
+			})
+		}
+	}
+	sort.SliceStable(steps, func(i, j int) bool {
+		a, b := steps[i], steps[j]
+		if phaseIndex(a.Phase) != phaseIndex(b.Phase) {
+			return phaseIndex(a.Phase) < phaseIndex(b.Phase)
+		}
+		if a.Wave != b.Wave {
+			return a.Wave < b.Wave
+		}
+		if kindIndex(a.Kind) != kindIndex(b.Kind) {
+			return kindIndex(a.Kind) < kindIndex(b.Kind)
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return steps
+}
+
+// WriteSyncOrder writes the sync order of the objects of each given Application, as a table
+func WriteSyncOrder(w io.Writer, apps []RenderedApplication) error {
+	for i, app := range apps {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "Application %s (%s)\n", app.Name, app.Path); err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "PHASE\tWAVE\tKIND\tNAMESPACE\tNAME")
+		for _, s := range SyncOrder(app.Objects) {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", s.Phase, s.Wave, s.Kind, s.Namespace, s.Name)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifies the sync waves and hooks of the rendered objects, and reports the CRDs and Namespaces which are applied
+// after the objects which depend on them
+func checkSyncWaves(r *reporter, path string, objs []*yaml.RNode) {
+	for _, obj := range objs {
+		annotations := obj.GetAnnotations()
+		if _, valid := syncWave(obj); !valid {
+			r.report(InvalidSyncWaveRule, Finding{
+				Path:    path,
+				Message: "sync wave is not an integer",
+				KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "wave", annotations[SyncWaveAnnotation]},
+			})
+		}
+		if hook, found := annotations[HookAnnotation]; found {
+			for _, h := range strings.Split(hook, ",") {
+				h = strings.TrimSpace(h)
+				if h == skipHook || phaseIndex(SyncPhase(h)) < len(syncPhases) {
+					continue
+				}
+				r.report(InvalidHookRule, Finding{
+					Path:    path,
+					Message: "unknown hook type",
+					KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "hook", h},
+				})
+			}
+		}
+		if policy, found := annotations[HookDeletePolicyAnnotation]; found {
+			for _, p := range strings.Split(policy, ",") {
+				p = strings.TrimSpace(p)
+				if hookDeletePolicies[p] {
+					continue
+				}
+				r.report(InvalidHookRule, Finding{
+					Path:    path,
+					Message: "unknown hook delete policy",
+					KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "policy", p},
+				})
+			}
+		}
+	}
+
+	// first phase and wave in which each CRD and Namespace is applied
+	type position struct {
+		phase int
+		wave  int
+	}
+	positionOf := func(obj *yaml.RNode) (position, bool) {
+		phases := syncPhasesOf(obj)
+		if len(phases) == 0 {
+			return position{}, false
+		}
+		wave, _ := syncWave(obj)
+		return position{phase: phaseIndex(phases[0]), wave: wave}, true
+	}
+	after := func(a, b position) bool {
+		return a.phase > b.phase || (a.phase == b.phase && a.wave > b.wave)
+	}
+	crds := map[string]*yaml.RNode{}
+	namespaces := map[string]*yaml.RNode{}
+	for _, obj := range objs {
+		switch obj.GetKind() {
+		case "CustomResourceDefinition":
+			group, _ := obj.GetString("spec.group")
+			kind, _ := obj.GetString("spec.names.kind")
+			crds[group+"/"+kind] = obj
+		case "Namespace":
+			namespaces[obj.GetName()] = obj
+		}
+	}
+	for _, obj := range objs {
+		pos, applied := positionOf(obj)
+		if !applied {
+			continue
+		}
+		group := ""
+		if i := strings.Index(obj.GetApiVersion(), "/"); i > 0 {
+			group = obj.GetApiVersion()[:i]
+		}
+		if crd, found := crds[group+"/"+obj.GetKind()]; found {
+			if crdPos, ok := positionOf(crd); ok && after(crdPos, pos) {
+				r.report(SyncOrderRule, Finding{
+					Path:    path,
+					Message: "CustomResourceDefinition is applied after its custom resources",
+					KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "crd", crd.GetName()},
+				})
+			}
+		}
+		if ns, found := namespaces[obj.GetNamespace()]; found {
+			if nsPos, ok := positionOf(ns); ok && after(nsPos, pos) {
+				r.report(SyncOrderRule, Finding{
+					Path:    path,
+					Message: "Namespace is applied after the objects placed in it",
+					KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "namespace", ns.GetName()},
+				})
+			}
+		}
+	}
+}
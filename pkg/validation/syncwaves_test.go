@@ -0,0 +1,251 @@
+package validation_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestCheckSyncWaves(t *testing.T) {
+
+	newFS := func(t *testing.T, resources string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- resources.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/resources.yaml", resources)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `apiVersion: v1
+kind: Namespace
+metadata:
+  name: cookie
+  annotations:
+    argocd.argoproj.io/sync-wave: "-1"
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: cookie
+  annotations:
+    argocd.argoproj.io/hook: PostSync,SyncFail
+    argocd.argoproj.io/hook-delete-policy: HookSucceeded,BeforeHookCreation
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: cookie:1.0.0
+      restartPolicy: Never`)
+
+		// when
+		err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("invalid wave and hooks", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+  annotations:
+    argocd.argoproj.io/sync-wave: first
+    argocd.argoproj.io/hook: PreSynk
+    argocd.argoproj.io/hook-delete-policy: HookSucceded`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 3 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "sync wave is not an integer",
+					KeyVals: []interface{}{
+						"rule", "ACK015",
+						"path", "/path/to/components/cookie",
+						"kind", "ConfigMap",
+						"name", "cookie",
+						"wave", "first",
+					},
+				},
+				{
+					Msg: "unknown hook type",
+					KeyVals: []interface{}{
+						"rule", "ACK016",
+						"path", "/path/to/components/cookie",
+						"kind", "ConfigMap",
+						"name", "cookie",
+						"hook", "PreSynk",
+					},
+				},
+				{
+					Msg: "unknown hook delete policy",
+					KeyVals: []interface{}{
+						"rule", "ACK016",
+						"path", "/path/to/components/cookie",
+						"kind", "ConfigMap",
+						"name", "cookie",
+						"policy", "HookSucceded",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("crd and namespace in later waves", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: cookies.example.com
+  annotations:
+    argocd.argoproj.io/sync-wave: "2"
+spec:
+  group: example.com
+  names:
+    kind: Cookie
+    plural: cookies
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: cookie
+  annotations:
+    argocd.argoproj.io/sync-wave: "1"
+---
+apiVersion: example.com/v1
+kind: Cookie
+metadata:
+  name: chocolate
+  namespace: cookie`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "CustomResourceDefinition is applied after its custom resources",
+					KeyVals: []interface{}{
+						"rule", "ACK017",
+						"path", "/path/to/components/cookie",
+						"kind", "Cookie",
+						"name", "chocolate",
+						"crd", "cookies.example.com",
+					},
+				},
+				{
+					Msg: "Namespace is applied after the objects placed in it",
+					KeyVals: []interface{}{
+						"rule", "ACK017",
+						"path", "/path/to/components/cookie",
+						"kind", "Cookie",
+						"name", "chocolate",
+						"namespace", "cookie",
+					},
+				},
+			}, logger.Warnings())
+		})
+	})
+}
+
+func TestWriteSyncOrder(t *testing.T) {
+	// given
+	namespace, err := yaml.Parse(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: cookie`)
+	require.NoError(t, err)
+	deployment, err := yaml.Parse(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cookie
+  namespace: cookie
+  annotations:
+    argocd.argoproj.io/sync-wave: "1"`)
+	require.NoError(t, err)
+	job, err := yaml.Parse(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  namespace: cookie
+  annotations:
+    argocd.argoproj.io/hook: PreSync`)
+	require.NoError(t, err)
+	configMap, err := yaml.Parse(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+  namespace: cookie
+  annotations:
+    argocd.argoproj.io/sync-wave: "1"`)
+	require.NoError(t, err)
+	skipped, err := yaml.Parse(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: skipped
+  namespace: cookie
+  annotations:
+    argocd.argoproj.io/hook: Skip`)
+	require.NoError(t, err)
+	out := &strings.Builder{}
+
+	// when
+	err = validation.WriteSyncOrder(out, []validation.RenderedApplication{
+		{
+			Name:    "cookie",
+			Path:    "/path/to/apps/cookie.yaml",
+			Objects: []*yaml.RNode{deployment, configMap, job, namespace, skipped},
+		},
+	})
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, `Application cookie (/path/to/apps/cookie.yaml)
+PHASE    WAVE  KIND        NAMESPACE  NAME
+PreSync  0     Job         cookie     migrate
+Sync     0     Namespace              cookie
+Sync     1     ConfigMap   cookie     cookie
+Sync     1     Deployment  cookie     cookie
+`, out.String())
+}
@@ -0,0 +1,251 @@
+package validation
+
+import (
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"path/filepath"
+	"strings"
+
+	argocdv1alpha1 "github.com/codeready-toolchain/argocd-checker/pkg/argocd-types/application/v1alpha1"
+
+	"github.com/spf13/afero"
+	kfsys "sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ApplicationNode is an Application or ApplicationSet of an app-of-apps tree
+type ApplicationNode struct {
+	// Kind is `Application` or `ApplicationSet`
+	Kind string
+	// Name of the Application or ApplicationSet
+	Name string
+	// Path of the file in which the object is declared, or of the source directory from which it was rendered
+	Path string
+	// Children are the Applications and ApplicationSets rendered from the sources of this object
+	Children []*ApplicationNode
+	// Cycle is true if the object is one of its own ancestors, in which case its sources are not rendered again
+	Cycle bool
+}
+
+// CheckApplicationTree checks the Applications and ApplicationSets found in the given root paths, then renders their
+// sources and checks the Applications and ApplicationSets found in the output, recursively (app-of-apps pattern).
+// Returns the tree of the Applications, whose cycles are reported.
+func CheckApplicationTree(logger Logger, afs afero.Afero, opts Options, baseDir string, roots ...string) ([]*ApplicationNode, error) {
+	r := newReporter(logger, opts)
+	nodes, err := checkApplicationTree(logger, r, afs, opts, baseDir, roots...)
+	if err != nil {
+		return nodes, err
+	}
+	return nodes, r.err()
+}
+
+// checks the app-of-apps trees of the given roots, and reports the findings with the given reporter
+func checkApplicationTree(logger Logger, r *reporter, afs afero.Afero, opts Options, baseDir string, roots ...string) ([]*ApplicationNode, error) {
+	fsys, err := NewInMemoryFS(logger, afs, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveRemoteResources(logger, afs, fsys, opts, baseDir); err != nil {
+		return nil, err
+	}
+	if err := resolveHelmCharts(logger, afs, fsys, opts, baseDir); err != nil {
+		return nil, err
+	}
+	if err := resolveSOPS(logger, fsys, opts, baseDir); err != nil {
+		return nil, err
+	}
+	t := &applicationTree{
+		logger:  logger,
+		r:       r,
+		afs:     afs,
+		fsys:    fsys,
+		opts:    opts,
+		baseDir: baseDir,
+		names:   applicationNames{},
+		visited: map[string]*ApplicationNode{},
+	}
+	nodes := []*ApplicationNode{}
+	for _, root := range roots {
+		p := filepath.Join(baseDir, root)
+		logger.Info("🌳 checking Applications from root", "path", p)
+		if err := afs.Walk(p, func(path string, info iofs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if opts.excluded(baseDir, path) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.IsDir() || filepath.Ext(info.Name()) != ".yaml" {
+				return nil
+			}
+			data, err := afs.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			objs, err := readObjects(data)
+			if err != nil {
+				logger.Debug("skipping invalid YAML file", "path", path, "err", err)
+				return nil
+			}
+			children, err := t.visitAll(path, objs, nil)
+			nodes = append(nodes, children...)
+			return err
+		}); err != nil {
+			// the nodes which were visited are returned, so that the partial tree can be written
+			return nodes, err
+		}
+	}
+	return nodes, nil
+}
+
+// applicationTree holds the state of the traversal of an app-of-apps tree
+type applicationTree struct {
+	logger  Logger
+	r       *reporter
+	afs     afero.Afero
+	fsys    kfsys.FileSystem
+	opts    Options
+	baseDir string
+	names   applicationNames
+	// visited are the nodes which were already checked, indexed by kind, namespace, name and path (eg: an
+	// Application included by several parents)
+	visited map[string]*ApplicationNode
+}
+
+// returns the key of the given Application or ApplicationSet, which identifies it in the cluster
+func (t *applicationTree) key(obj *yaml.RNode) string {
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = t.opts.argocdNamespace()
+	}
+	return fmt.Sprintf("%s/%s/%s", obj.GetKind(), namespace, obj.GetName())
+}
+
+// checks the Applications and ApplicationSets among the given objects, declared (or rendered) in the given path,
+// and visits their sources
+func (t *applicationTree) visitAll(path string, objs []*yaml.RNode, ancestors []string) ([]*ApplicationNode, error) {
+	nodes := []*ApplicationNode{}
+	appObjs := []*yaml.RNode{}
+	for _, obj := range objs {
+		sources, err := applicationSources(obj)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in %s: %w", obj.GetKind(), path, err)
+		}
+		if sources == nil {
+			continue
+		}
+		if _, found := t.visited[t.key(obj)+" "+path]; !found {
+			appObjs = append(appObjs, obj)
+		}
+		node, err := t.visit(path, obj, sources, ancestors)
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+		if err != nil {
+			return nodes, err
+		}
+	}
+	if len(appObjs) > 0 {
+		if err := t.r.checkApplications(path, appObjs); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// checks the given Application or ApplicationSet and renders its sources to visit its children, unless it is one
+// of its own ancestors, or it was already visited through another parent (in which case the same node is returned)
+func (t *applicationTree) visit(path string, obj *yaml.RNode, sources argocdv1alpha1.ApplicationSources, ancestors []string) (*ApplicationNode, error) {
+	node := &ApplicationNode{
+		Kind: obj.GetKind(),
+		Name: obj.GetName(),
+		Path: path,
+	}
+	key := t.key(obj)
+	for i, a := range ancestors {
+		if a == key {
+			node.Cycle = true
+			t.r.report(ApplicationCycleRule, Finding{
+				Path:    path,
+				Message: "Application deploys itself through its children",
+				KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "cycle", strings.Join(append(append([]string{}, ancestors[i:]...), key), " → ")},
+			})
+			return node, nil
+		}
+	}
+	if visited, found := t.visited[key+" "+path]; found {
+		return visited, nil
+	}
+	t.visited[key+" "+path] = node
+	if err := checkApplication(t.logger, t.r, t.afs, t.fsys, t.opts, t.names, t.baseDir, path, obj, sources); err != nil {
+		return nil, err
+	}
+	ancestors = append(append([]string{}, ancestors...), key)
+	for _, s := range sources {
+		if s.Path == "" || strings.Contains(s.Path, "{{") {
+			// eg: Helm chart or templated path of an ApplicationSet
+			continue
+		}
+		dir := filepath.Join(t.baseDir, s.Path)
+		if isDir, err := t.afs.IsDir(dir); err != nil || !isDir {
+			// reported as an invalid source path
+			continue
+		}
+		if t.opts.excluded(t.baseDir, dir) {
+			t.logger.Debug("skipping excluded source", "path", dir)
+			continue
+		}
+		t.logger.Debug("rendering source", "kind", obj.GetKind(), "name", obj.GetName(), "source", dir)
+		objs, err := renderSource(t.logger, t.afs, t.fsys, t.opts, dir)
+		if err != nil {
+			// the children of the source are unknown, but the other sources and Applications are still visited
+			t.r.report(KustomizeBuildRule, Finding{
+				Path:    dir,
+				Message: "kustomize build failed",
+				KeyVals: []interface{}{"kind", obj.GetKind(), "name", obj.GetName(), "err", err},
+			})
+			continue
+		}
+		children, err := t.visitAll(dir, objs, ancestors)
+		node.Children = append(node.Children, children...)
+		if err != nil {
+			return node, err
+		}
+	}
+	return node, nil
+}
+
+// WriteApplicationTree writes the given app-of-apps tree, one Application or ApplicationSet per line
+func WriteApplicationTree(w io.Writer, nodes []*ApplicationNode) error {
+	for _, n := range nodes {
+		if err := writeApplicationNode(w, n, "", ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writes the given node with the given prefix, and its children with the given indentation
+func writeApplicationNode(w io.Writer, n *ApplicationNode, prefix, indent string) error {
+	line := fmt.Sprintf("%s%s/%s (%s)", prefix, n.Kind, n.Name, n.Path)
+	if n.Cycle {
+		line += " ↺ cycle"
+	}
+	if _, err := fmt.Fprintln(w, line); err != nil {
+		return err
+	}
+	for i, c := range n.Children {
+		branch, next := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			branch, next = "└── ", "    "
+		}
+		if err := writeApplicationNode(w, c, indent+branch, indent+next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package validation_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckApplicationTree(t *testing.T) {
+
+	newFS := func(t *testing.T, childApps string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/apps/root.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: root
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: apps-of-apps`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/apps-of-apps/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- apps.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/apps-of-apps/apps.yaml", childApps)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/configmap.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cookie
+data:
+  flavor: chocolate`)
+		require.NoError(t, err)
+		return afs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie
+---
+apiVersion: argoproj.io/v1alpha1
+kind: ApplicationSet
+metadata:
+  name: pastas
+spec:
+  generators:
+  - list:
+      elements:
+      - flavor: pesto
+  template:
+    metadata:
+      name: 'pasta-{{flavor}}'
+    spec:
+      destination:
+        server: https://kubernetes.default.svc
+      source:
+        path: 'components/pasta/{{flavor}}'`)
+
+		// when
+		tree, err := validation.CheckApplicationTree(logger, afs, validation.Options{}, "/path/to", "apps")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		out := &strings.Builder{}
+		err = validation.WriteApplicationTree(out, tree)
+		require.NoError(t, err)
+		assert.Equal(t, `Application/root (/path/to/apps/root.yaml)
+├── Application/cookie (/path/to/apps-of-apps)
+└── ApplicationSet/pastas (/path/to/apps-of-apps)
+`, out.String())
+	})
+
+	t.Run("shared child application", func(t *testing.T) {
+		// given
+		logger := NewTestLogger(os.Stdout, charmlog.Options{
+			Level: charmlog.InfoLevel,
+		})
+		// both app-of-apps include the same directory of Applications
+		afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: left
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: apps-common
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: right
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: apps-common`)
+		err := addFile(afs, "/path/to/apps-common/cookie.yaml", `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`)
+		require.NoError(t, err)
+
+		// when
+		tree, err := validation.CheckApplicationTree(logger, afs, validation.Options{}, "/path/to", "apps")
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, logger.Errors())
+		assert.Empty(t, logger.Warnings())
+		out := &strings.Builder{}
+		err = validation.WriteApplicationTree(out, tree)
+		require.NoError(t, err)
+		assert.Equal(t, `Application/root (/path/to/apps/root.yaml)
+├── Application/left (/path/to/apps-of-apps)
+│   └── Application/cookie (/path/to/apps-common)
+└── Application/right (/path/to/apps-of-apps)
+    └── Application/cookie (/path/to/apps-common)
+`, out.String())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("invalid child application", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/unknown`)
+
+			// when
+			_, err := validation.CheckApplicationTree(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "invalid source path",
+					KeyVals: []interface{}{
+						"rule", "ACK003",
+						"path", "/path/to/apps-of-apps",
+						"kind", "Application",
+						"name", "cookie",
+						"source", "components/unknown",
+					},
+				},
+			}, logger.Errors())
+		})
+
+		t.Run("broken child source", func(t *testing.T) {
+			newBrokenFS := func(t *testing.T) afero.Afero {
+				afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: broken
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: apps-broken
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: components/cookie`)
+				err := addFile(afs, "/path/to/apps-broken/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- missing.yaml`)
+				require.NoError(t, err)
+				return afs
+			}
+
+			t.Run("rule enabled", func(t *testing.T) {
+				// given
+				logger := NewTestLogger(os.Stdout, charmlog.Options{
+					Level: charmlog.InfoLevel,
+				})
+				afs := newBrokenFS(t)
+
+				// when
+				tree, err := validation.CheckApplicationTree(logger, afs, validation.Options{}, "/path/to", "apps")
+
+				// then
+				require.EqualError(t, err, "found 1 error(s)")
+				require.Len(t, logger.Errors(), 1)
+				assert.Equal(t, "kustomize build failed", logger.Errors()[0].Msg)
+				assert.Equal(t, []interface{}{"rule", "ACK002", "path", "/path/to/apps-broken", "kind", "Application", "name", "broken"}, logger.Errors()[0].KeyVals[:8])
+				// the other Applications are still visited
+				out := &strings.Builder{}
+				err = validation.WriteApplicationTree(out, tree)
+				require.NoError(t, err)
+				assert.Equal(t, `Application/root (/path/to/apps/root.yaml)
+├── Application/broken (/path/to/apps-of-apps)
+└── Application/cookie (/path/to/apps-of-apps)
+`, out.String())
+			})
+
+			t.Run("rule disabled", func(t *testing.T) {
+				// given
+				logger := NewTestLogger(os.Stdout, charmlog.Options{
+					Level: charmlog.InfoLevel,
+				})
+				afs := newBrokenFS(t)
+				rules := validation.NewRegistry()
+				err := rules.SetEnabled("kustomize-build", false)
+				require.NoError(t, err)
+
+				// when
+				tree, err := validation.CheckApplicationTree(logger, afs, validation.Options{Rules: rules}, "/path/to", "apps")
+
+				// then
+				require.NoError(t, err)
+				assert.Empty(t, logger.Errors())
+				require.Len(t, tree, 1)
+				assert.Len(t, tree[0].Children, 2)
+			})
+		})
+
+		t.Run("cycle", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: cookie
+spec:
+  destination:
+    server: https://kubernetes.default.svc
+  source:
+    path: apps`)
+
+			// when
+			tree, err := validation.CheckApplicationTree(logger, afs, validation.Options{}, "/path/to", "apps")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "Application deploys itself through its children",
+					KeyVals: []interface{}{
+						"rule", "ACK014",
+						"path", "/path/to/apps",
+						"kind", "Application",
+						"name", "root",
+						"cycle", "Application/argocd/root → Application/argocd/cookie → Application/argocd/root",
+					},
+				},
+			}, logger.Errors())
+			out := &strings.Builder{}
+			err = validation.WriteApplicationTree(out, tree)
+			require.NoError(t, err)
+			assert.Equal(t, `Application/root (/path/to/apps/root.yaml)
+└── Application/cookie (/path/to/apps-of-apps)
+    └── Application/root (/path/to/apps) ↺ cycle
+`, out.String())
+		})
+	})
+}
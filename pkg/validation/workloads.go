@@ -0,0 +1,98 @@
+package validation
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// kinds of the long-running workloads, whose containers must have readiness and liveness probes
+var longRunningKinds = []string{"Pod", "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet"}
+
+// compute resources which must be set in the requests and limits of the containers
+var computeResources = []string{"requests.cpu", "requests.memory", "limits.cpu", "limits.memory"}
+
+// probes which must be set in the containers of the long-running workloads
+var containerProbes = []string{"readinessProbe", "livenessProbe"}
+
+// verifies the pod specs of the rendered workloads against the best practices: compute resources, probes and
+// security context. All these rules are disabled by default.
+func checkWorkloads(r *reporter, path string, objs []*yaml.RNode) {
+	for _, obj := range objs {
+		spec, found := podSpec(obj)
+		if !found {
+			continue
+		}
+		keyVals := []interface{}{"kind", obj.GetKind(), "name", obj.GetName()}
+		if hostNetwork, _ := spec.Pipe(yaml.Lookup("hostNetwork")); yaml.GetValue(hostNetwork) == "true" {
+			r.report(HostNetworkRule, Finding{
+				Path:    path,
+				Message: "pod uses the host network",
+				KeyVals: keyVals,
+			})
+		}
+		podNonRoot, _ := spec.Pipe(yaml.Lookup("securityContext", "runAsNonRoot"))
+		for _, field := range []string{"initContainers", "containers"} {
+			f := spec.Field(field)
+			if f == nil {
+				continue
+			}
+			containers, err := f.Value.Elements()
+			if err != nil {
+				continue
+			}
+			for _, c := range containers {
+				name, _ := c.GetString("name")
+				keyVals := append(append([]interface{}{}, keyVals...), "container", name)
+				missing := []string{}
+				for _, res := range computeResources {
+					if v, _ := c.Pipe(yaml.Lookup(append([]string{"resources"}, strings.Split(res, ".")...)...)); v == nil {
+						missing = append(missing, res)
+					}
+				}
+				if len(missing) > 0 {
+					r.report(MissingResourcesRule, Finding{
+						Path:    path,
+						Message: "container is missing compute resource requests or limits",
+						KeyVals: append(append([]interface{}{}, keyVals...), "missing", missing),
+					})
+				}
+				if field == "containers" && contains(longRunningKinds, obj.GetKind()) {
+					missing := []string{}
+					for _, p := range containerProbes {
+						if c.Field(p) == nil {
+							missing = append(missing, p)
+						}
+					}
+					if len(missing) > 0 {
+						r.report(MissingProbesRule, Finding{
+							Path:    path,
+							Message: "container is missing readiness or liveness probes",
+							KeyVals: append(append([]interface{}{}, keyVals...), "missing", missing),
+						})
+					}
+				}
+				if privileged, _ := c.Pipe(yaml.Lookup("securityContext", "privileged")); yaml.GetValue(privileged) == "true" {
+					r.report(PrivilegedContainerRule, Finding{
+						Path:    path,
+						Message: "container is privileged",
+						KeyVals: keyVals,
+					})
+				}
+				// the security context of the container overrides the one of the pod
+				nonRoot, _ := c.Pipe(yaml.Lookup("securityContext", "runAsNonRoot"))
+				if nonRoot == nil {
+					nonRoot = podNonRoot
+				}
+				if yaml.GetValue(nonRoot) != "true" {
+					r.report(RunAsNonRootRule, Finding{
+						Path:       path,
+						Message:    "container may run as root",
+						KeyVals:    keyVals,
+						Suggestion: "set 'securityContext.runAsNonRoot: true' in the pod or in the container",
+					})
+				}
+			}
+		}
+	}
+}
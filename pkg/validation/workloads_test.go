@@ -0,0 +1,246 @@
+package validation_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeready-toolchain/argocd-checker/pkg/validation"
+
+	charmlog "github.com/charmbracelet/log"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckWorkloads(t *testing.T) {
+
+	newFS := func(t *testing.T, workloads string) afero.Afero {
+		afs := afero.Afero{
+			Fs: afero.NewMemMapFs(),
+		}
+		err := addFile(afs, "/path/to/components/cookie/kustomization.yaml", `kind: Kustomization
+apiVersion: kustomize.config.k8s.io/v1beta1
+resources:
+- workloads.yaml`)
+		require.NoError(t, err)
+		err = addFile(afs, "/path/to/components/cookie/workloads.yaml", workloads)
+		require.NoError(t, err)
+		return afs
+	}
+
+	// enables all the best-practice rules, which are disabled by default
+	newRegistry := func(t *testing.T) *validation.Registry {
+		rules := validation.NewRegistry()
+		for _, id := range []string{"ACK025", "ACK026", "ACK027", "ACK028", "ACK029"} {
+			err := rules.SetEnabled(id, true)
+			require.NoError(t, err)
+		}
+		return rules
+	}
+
+	insecure := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cookie
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      containers:
+      - name: cookie
+        image: quay.io/org/cookie:v1.2.3
+        resources:
+          requests:
+            cpu: 100m
+        securityContext:
+          privileged: true`
+
+	t.Run("success", func(t *testing.T) {
+
+		t.Run("best practices", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cookie
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: true
+      containers:
+      - name: cookie
+        image: quay.io/org/cookie:v1.2.3
+        resources:
+          requests:
+            cpu: 100m
+            memory: 128Mi
+          limits:
+            cpu: 500m
+            memory: 256Mi
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: 8080
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: 8080
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      containers:
+      - name: migrate
+        image: quay.io/org/cookie:v1.2.3
+        resources:
+          requests:
+            cpu: 100m
+            memory: 128Mi
+          limits:
+            cpu: 500m
+            memory: 256Mi
+        securityContext:
+          runAsNonRoot: true`)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{Rules: newRegistry(t)}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+
+		t.Run("disabled by default", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, insecure)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{}, "/path/to", "components")
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, logger.Errors())
+			assert.Empty(t, logger.Warnings())
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+
+		t.Run("all rules enabled", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, insecure)
+
+			// when
+			err := validation.CheckComponents(logger, afs, validation.Options{Rules: newRegistry(t)}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 3 error(s)")
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "pod uses the host network",
+					KeyVals: []interface{}{
+						"rule", "ACK028",
+						"path", "/path/to/components/cookie",
+						"kind", "Deployment",
+						"name", "cookie",
+					},
+				},
+				{
+					Msg: "container is privileged",
+					KeyVals: []interface{}{
+						"rule", "ACK027",
+						"path", "/path/to/components/cookie",
+						"kind", "Deployment",
+						"name", "cookie",
+						"container", "cookie",
+					},
+				},
+				{
+					Msg: "container may run as root",
+					KeyVals: []interface{}{
+						"rule", "ACK029",
+						"path", "/path/to/components/cookie",
+						"kind", "Deployment",
+						"name", "cookie",
+						"container", "cookie",
+						"suggestion", "set 'securityContext.runAsNonRoot: true' in the pod or in the container",
+					},
+				},
+			}, logger.Errors())
+			assert.Equal(t, []LogRecord{
+				{
+					Msg: "container is missing compute resource requests or limits",
+					KeyVals: []interface{}{
+						"rule", "ACK025",
+						"path", "/path/to/components/cookie",
+						"kind", "Deployment",
+						"name", "cookie",
+						"container", "cookie",
+						"missing", []string{"requests.memory", "limits.cpu", "limits.memory"},
+					},
+				},
+				{
+					Msg: "container is missing readiness or liveness probes",
+					KeyVals: []interface{}{
+						"rule", "ACK026",
+						"path", "/path/to/components/cookie",
+						"kind", "Deployment",
+						"name", "cookie",
+						"container", "cookie",
+						"missing", []string{"readinessProbe", "livenessProbe"},
+					},
+				},
+			}, logger.Warnings())
+		})
+
+		t.Run("container overrides runAsNonRoot", func(t *testing.T) {
+			// given
+			logger := NewTestLogger(os.Stdout, charmlog.Options{
+				Level: charmlog.InfoLevel,
+			})
+			afs := newFS(t, `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          securityContext:
+            runAsNonRoot: true
+          containers:
+          - name: cleanup
+            image: registry.k8s.io/kubectl:v1.28.0
+            securityContext:
+              runAsNonRoot: false`)
+			rules := validation.NewRegistry()
+			err := rules.SetEnabled("run-as-non-root", true)
+			require.NoError(t, err)
+
+			// when
+			err = validation.CheckComponents(logger, afs, validation.Options{Rules: rules}, "/path/to", "components")
+
+			// then
+			require.EqualError(t, err, "found 1 error(s)")
+			require.Len(t, logger.Errors(), 1)
+			assert.Equal(t, "container may run as root", logger.Errors()[0].Msg)
+			assert.Empty(t, logger.Warnings())
+		})
+	})
+}